@@ -18,6 +18,8 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 /**
@@ -53,6 +55,7 @@ const (
 	PrimaryRole    MemberRole = "primary"
 	SencondaryRole MemberRole = "sencondary"
 	ReplicaofRole  MemberRole = "replicaof"
+	DelayedRole    MemberRole = "delayed"
 )
 
 // PodSpec defines the desired state of Pod
@@ -74,9 +77,35 @@ type PodSpec struct {
 }
 
 type Storage struct {
-	Type                          string                            `json:"type,omitempty"`
-	PersistentVolumeSource        *corev1.PersistentVolumeSource    `json:"persistentVolumeSource,omitempty"`
+	Type string `json:"type,omitempty"`
+	// PersistentVolumeSource makes the operator create the persistentVolume and
+	// pre-bind it to the data claim, for clusters without dynamic provisioning
+	PersistentVolumeSource *corev1.PersistentVolumeSource `json:"persistentVolumeSource,omitempty"`
+	// PersistentVolumeClaimTemplate of the data claim. A dataSource restores a
+	// snapshot or another claim, the restored data keeps the accounts of its
+	// source so MYSQL_ROOT_PASSWORD has to be the root password of the source.
 	PersistentVolumeClaimTemplate *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaimTemplate,omitempty"`
+	// BinlogVolume moves the binary and relay logs off the data volume. The
+	// binlog, redo and log volumes can only be set when the instance is created.
+	//+optional
+	BinlogVolume *corev1.PersistentVolumeClaimSpec `json:"binlogVolume,omitempty"`
+	// RedoVolume moves the redo and undo logs off the data volume
+	//+optional
+	RedoVolume *corev1.PersistentVolumeClaimSpec `json:"redoVolume,omitempty"`
+	// LogVolume moves the error and slow query logs off the data volume
+	//+optional
+	LogVolume *corev1.PersistentVolumeClaimSpec `json:"logVolume,omitempty"`
+	// VolumeSnapshotClassName of the snapshot backups, the default class if empty
+	//+optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+	// Autoscaling grows the data volume before it runs full
+	//+optional
+	Autoscaling *StorageAutoscalingSpec `json:"autoscaling,omitempty"`
+	// ReclaimPolicy of the persistentVolume created from persistentVolumeSource
+	//+kubebuilder:validation:Enum=Retain;Delete
+	//+kubebuilder:default=Retain
+	//+optional
+	ReclaimPolicy corev1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
 }
 
 // PodAffinity defines the affinity/anti-affinity rules for the pod.
@@ -96,7 +125,8 @@ type VolumeSpec struct {
 
 	//  HostPath to use as data volume for mysql. HostPath represents a
 	// pre-existing file or directory on the host machine that is directly
-	// exposed to the container.
+	// exposed to the container. Only the single type supports it and its pod
+	// has to be pinned with podSpec.nodeSelector or a required node affinity.
 	HostPath *corev1.HostPathVolumeSource `json:"hostPath,omitempty"`
 
 	// PersistentVolumeClaim to specify PVC spec for the volume for mysql data.
@@ -118,8 +148,286 @@ type ContainerSpec struct {
 	Envs             []corev1.EnvVar               `json:"envs,omitempty"`             // Environment variables
 }
 
+// FailoverSpec defines how the operator reacts to a failed primary
+type FailoverSpec struct {
+	// Disabled stops the operator from promoting a replica on its own,
+	// a planned switchover is still honored
+	Disabled bool `json:"disabled,omitempty"`
+
+	// GracePeriodSeconds is how long the primary may stay unhealthy before it is replaced
+	//+kubebuilder:default=30
+	//+kubebuilder:validation:Minimum=0
+	GracePeriodSeconds int32 `json:"gracePeriodSeconds,omitempty"`
+}
+
+// ScalingSpec defines how members are removed when the size shrinks
+type ScalingSpec struct {
+	// DeletePVCOnScaleIn deletes the persistentVolumeClaim of a removed member,
+	// by default it is kept and reused when scaling out again
+	DeletePVCOnScaleIn bool `json:"deletePVCOnScaleIn,omitempty"`
+}
+
+// DataSource defines where the data of a new single comes from
+type DataSource struct {
+	// CloneFrom copies a running single with the clone plugin
+	//+optional
+	CloneFrom *CloneSource `json:"cloneFrom,omitempty"`
+	// External imports a server running outside the operator, the single
+	// replicates from it until the cutover annotation promotes it
+	//+optional
+	External *ExternalSource `json:"external,omitempty"`
+}
+
+// CloneSource references the single to clone
+type CloneSource struct {
+	Name string `json:"name"`
+	// Namespace of the source, the namespace of the new single if empty. A source
+	// in another namespace has to list it in its greatsql.cn/clone-allowed-namespaces
+	// annotation.
+	//+optional
+	Namespace string `json:"namespace,omitempty"`
+	// Replicate leaves the new single replicating asynchronously from the source
+	//+optional
+	Replicate bool `json:"replicate,omitempty"`
+}
+
+// ExternalSource references a server running outside the operator
+type ExternalSource struct {
+	Host string `json:"host"`
+	//+kubebuilder:default=3306
+	Port int32 `json:"port,omitempty"`
+	// CredentialsSecret holds the user and password keys of an account with
+	// REPLICATION SLAVE and, to seed with clone, BACKUP_ADMIN and CREATE USER
+	// on the source
+	CredentialsSecret string `json:"credentialsSecret"`
+	// SeedMethod copies the data before replicating. clone copies the accounts
+	// too, the operator sets root back to MYSQL_ROOT_PASSWORD with the account
+	// of the credentialsSecret. dump copies the user schemas only.
+	//+kubebuilder:validation:Enum=clone;dump
+	//+kubebuilder:default=clone
+	SeedMethod SeedMethod `json:"seedMethod,omitempty"`
+}
+
+type SeedMethod string
+
+const (
+	SeedMethodClone SeedMethod = "clone"
+	SeedMethodDump  SeedMethod = "dump"
+)
+
+// DelayedReplicaSpec defines the replicas applying transactions late, a copy
+// of the data from before a human error to recover from. They receive no
+// traffic and are never promoted.
+type DelayedReplicaSpec struct {
+	// DelaySeconds is the SOURCE_DELAY of the delayed replicas
+	//+kubebuilder:validation:Minimum=1
+	DelaySeconds int32 `json:"delaySeconds"`
+	// Ordinals of the delayed members, the highest ordinal if empty
+	//+optional
+	Ordinals []int32 `json:"ordinals,omitempty"`
+}
+
+// MaintenanceWindow defines a weekly time range disruptive changes are applied in
+type MaintenanceWindow struct {
+	// Days the window opens on, every day if empty
+	//+optional
+	Days []Weekday `json:"days,omitempty"`
+	// Start is the time of day the window opens, HH:MM
+	//+kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// End is the time of day the window closes, HH:MM. An end before the start
+	// closes the window on the next day.
+	//+kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// TimeZone of start and end as an IANA name such as Asia/Shanghai, UTC if empty
+	//+optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+//+kubebuilder:validation:Enum=Mon;Tue;Wed;Thu;Fri;Sat;Sun
+
+// Weekday is a day of the week a maintenance window opens on
+type Weekday string
+
+// StandbySpec references the primary instance a standby replicates from
+type StandbySpec struct {
+	// Host of the service or load balancer in front of the primary
+	Host string `json:"host"`
+	//+kubebuilder:default=3306
+	Port int32 `json:"port,omitempty"`
+	// CredentialsSecret holds the user and password keys of an account with
+	// REPLICATION SLAVE and BACKUP_ADMIN on the primary. The standby replicates
+	// the accounts of the primary and its members replicate with this account too.
+	// A single resets root to MYSQL_ROOT_PASSWORD with this account after the
+	// clone, which then needs CREATE USER. The members of a cluster need
+	// MYSQL_ROOT_PASSWORD to be the root password of the primary.
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
+// StandbyStatus defines the observed replication of a standby
+type StandbyStatus struct {
+	Source     string `json:"source,omitempty"`     // host:port replicated from
+	Member     string `json:"member,omitempty"`     // pod running the replication channel
+	LagSeconds *int64 `json:"lagSeconds,omitempty"` // Seconds_Behind_Source, empty while not replicating
+	LastError  string `json:"lastError,omitempty"`  // last replication error
+}
+
+// StorageAutoscalingSpec defines how the data volume grows with its usage
+type StorageAutoscalingSpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// ThresholdPercent of used space on any member that triggers an expansion
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=99
+	//+kubebuilder:default=80
+	ThresholdPercent int32 `json:"thresholdPercent,omitempty"`
+	// Step added to the storage request on every expansion
+	//+kubebuilder:default="10Gi"
+	Step resource.Quantity `json:"step,omitempty"`
+	// MaxSize the storage request never grows beyond
+	//+optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// PurgeBinlogs purges the binary logs every member already applied once
+	// the volume is at MaxSize and still above the threshold. Standbys of the
+	// instance count as members, nothing is purged while a replica the operator
+	// does not know is connected or when there is no replica at all.
+	PurgeBinlogs bool `json:"purgeBinlogs,omitempty"`
+}
+
+// VolumeUsage defines the observed filesystem usage of a data volume
+type VolumeUsage struct {
+	Pod           string `json:"pod"`
+	UsedBytes     int64  `json:"usedBytes,omitempty"`
+	CapacityBytes int64  `json:"capacityBytes,omitempty"`
+	UsedPercent   int32  `json:"usedPercent,omitempty"`
+}
+
+// MemberStatus defines the observed state of one member of the cluster
+type MemberStatus struct {
+	Name         string     `json:"name"`                   // pod name
+	Role         MemberRole `json:"role,omitempty"`         // role the operator assigned
+	State        string     `json:"state,omitempty"`        // member state
+	GtidExecuted string     `json:"gtidExecuted,omitempty"` // gtid_executed of the member
+}
+
+// Member states
+const (
+	MemberStateOnline      = "ONLINE"
+	MemberStateRecovering  = "RECOVERING"
+	MemberStateOffline     = "OFFLINE"
+	MemberStateUnreachable = "UNREACHABLE"
+	MemberStateError       = "ERROR"
+)
+
 // UpgradeOptions defines the desired state of UpgradeOptions
 type UpgradeOptions struct {
+	// VersionServiceEndpoint is the base url of the version service, its
+	// /versions path lists the available GreatSql images
 	VersionServiceEndpoint string `json:"versionServiceEndpoint,omitempty"`
-	Apply                  string `json:"apply,omitempty"`
+	// Apply is disabled, recommended, latest or an explicit version such as
+	// 8.0.32-25. Everything but disabled moves the image to that version.
+	Apply string `json:"apply,omitempty"`
+	// BackupBeforeUpgrade takes a volume snapshot of the primary before a new
+	// image is rolled out
+	BackupBeforeUpgrade bool `json:"backupBeforeUpgrade,omitempty"`
+	// MemberTimeoutSeconds is how long a restarted member may take to become
+	// healthy before the rollout halts
+	//+kubebuilder:default=600
+	//+kubebuilder:validation:Minimum=60
+	MemberTimeoutSeconds int32 `json:"memberTimeoutSeconds,omitempty"`
+}
+
+// Apply values of the UpgradeOptions
+const (
+	UpgradeApplyDisabled    = "disabled"
+	UpgradeApplyRecommended = "recommended"
+	UpgradeApplyLatest      = "latest"
+)
+
+// VersionStatus defines the running and available GreatSql versions
+type VersionStatus struct {
+	Running     string       `json:"running,omitempty"`     // @@version of the server
+	Image       string       `json:"image,omitempty"`       // image of the pod the version was read from
+	Recommended string       `json:"recommended,omitempty"` // newest recommended version of the version service
+	Latest      string       `json:"latest,omitempty"`      // newest version of the version service
+	CheckedAt   *metav1.Time `json:"checkedAt,omitempty"`   // last time the version service answered
+}
+
+// InitScript references a configMap or secret whose .sql keys run once after the
+// datadir is initialized, in the order of their keys
+type InitScript struct {
+	// ConfigMap holding the scripts
+	//+optional
+	ConfigMap *corev1.LocalObjectReference `json:"configMap,omitempty"`
+	// Secret holding the scripts, for scripts that contain passwords
+	//+optional
+	Secret *corev1.LocalObjectReference `json:"secret,omitempty"`
+}
+
+// InitScriptsStatus defines the execution of the init scripts
+type InitScriptsStatus struct {
+	Checksum string   `json:"checksum,omitempty"` // sha256 of the scripts last run
+	Executed []string `json:"executed,omitempty"` // scripts that succeeded, they are never run again
+	Failed   string   `json:"failed,omitempty"`   // script that failed, it runs again once the checksum changes
+}
+
+// HealthCheckSpec defines the checks of the default probes. Startup and liveness
+// pass while a clone replaces the data and while root still has the password of
+// the clone source, readiness waits until the operator reset it.
+type HealthCheckSpec struct {
+	// MaxReplicationLagSeconds a replica may fall behind its source and still be
+	// ready, on top of the SOURCE_DELAY of a delayed replica. 0 disables the check.
+	//+kubebuilder:default=60
+	//+kubebuilder:validation:Minimum=0
+	MaxReplicationLagSeconds int32 `json:"maxReplicationLagSeconds,omitempty"`
+}
+
+// TLSSpec defines the certificates of encrypted client and replication connections
+type TLSSpec struct {
+	// Issuer of the server certificate. Operator signs it with a CA of its own
+	// and renews it before it expires, CertManager requests it from issuerRef and
+	// Secret uses secretName as is. Renewed certificates are loaded without restart.
+	//+kubebuilder:validation:Enum=Operator;CertManager;Secret
+	//+kubebuilder:default=Operator
+	Issuer TLSIssuer `json:"issuer,omitempty"`
+	// IssuerRef is the cert-manager issuer of the certificate, required by CertManager
+	//+optional
+	IssuerRef *IssuerReference `json:"issuerRef,omitempty"`
+	// SecretName of a secret with tls.crt, tls.key and ca.crt, required by Secret
+	//+optional
+	SecretName string `json:"secretName,omitempty"`
+	// RequireSecureTransport rejects unencrypted connections
+	//+optional
+	RequireSecureTransport bool `json:"requireSecureTransport,omitempty"`
+	// MutualTLS gives every member of a cluster type a client certificate of the
+	// operator CA. Replication, distributed recovery and group communication
+	// verify them, so servers of other clusters can neither replicate nor join.
+	// Requires the Operator issuer. Group members switch to encrypted group
+	// communication when they rejoin, enabling tls on a running group takes
+	// a restart of the whole group.
+	//+optional
+	MutualTLS bool `json:"mutualTLS,omitempty"`
+}
+
+// TLSIssuer is who issues the server certificate
+type TLSIssuer string
+
+const (
+	TLSIssuerOperator    TLSIssuer = "Operator"
+	TLSIssuerCertManager TLSIssuer = "CertManager"
+	TLSIssuerSecret      TLSIssuer = "Secret"
+)
+
+// IssuerReference references a cert-manager Issuer or ClusterIssuer
+type IssuerReference struct {
+	Name string `json:"name"`
+	//+kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	//+kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
+	//+kubebuilder:default=cert-manager.io
+	Group string `json:"group,omitempty"`
+}
+
+// TLSStatus defines the certificate the servers use
+type TLSStatus struct {
+	NotAfter *metav1.Time `json:"notAfter,omitempty"` // expiry of the current server certificate
 }
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 greatsql.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-22 14:05:19
+ * @file: greatsqldatabase_types.go
+ * @description: greatsql database types
+ */
+
+// GreatSQLDatabaseSpec defines the desired state of GreatSQLDatabase
+type GreatSQLDatabaseSpec struct {
+	// SingleRef is the name of the Single in the same namespace the schema is created on
+	SingleRef string `json:"singleRef"`
+	// Name of the schema, the name of the GreatSQLDatabase if empty. The schemas
+	// of the server can not be managed.
+	//+kubebuilder:validation:MaxLength=64
+	//+kubebuilder:validation:XValidation:rule="self == oldSelf",message="name is immutable"
+	//+optional
+	Name string `json:"name,omitempty"`
+	// CharacterSet is the default character set of the schema
+	//+kubebuilder:default=utf8mb4
+	//+kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	CharacterSet string `json:"characterSet,omitempty"`
+	// Collation is the default collation of the schema, the default of the character set if empty
+	//+kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	//+optional
+	Collation string `json:"collation,omitempty"`
+	// DeletionPolicy is what happens to the schema when the resource is deleted
+	//+kubebuilder:validation:Enum=Retain;Delete
+	//+kubebuilder:default=Retain
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Users generates accounts for the schema, each with a secret holding its dsn
+	//+optional
+	Users DatabaseUsers `json:"users,omitempty"`
+}
+
+// DeletionPolicy is what happens to the schema of a deleted GreatSQLDatabase
+type DeletionPolicy string
+
+const (
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// DatabaseUsers defines the accounts generated for a schema
+type DatabaseUsers struct {
+	// Owner is given every privilege on the schema, its secret is <name>-owner
+	//+optional
+	Owner bool `json:"owner,omitempty"`
+	// Readonly may only read the schema, its secret is <name>-readonly. Its dsn
+	// points at the read service of the replicated topologies.
+	//+optional
+	Readonly bool `json:"readonly,omitempty"`
+}
+
+// GreatSQLDatabaseStatus defines the observed state of GreatSQLDatabase
+type GreatSQLDatabaseStatus struct {
+	Name               string   `json:"name,omitempty"`  // schema that was created
+	Users              []string `json:"users,omitempty"` // GreatSQLUsers generated for the schema
+	ObservedGeneration int64    `json:"observedGeneration,omitempty"`
+
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Single",type="string",JSONPath=".spec.singleRef",description="The single the schema is created on"
+//+kubebuilder:printcolumn:name="Database",type="string",JSONPath=".status.name",description="The name of the schema"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the schema is in sync"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:validation:XValidation:rule="!((has(self.spec.name) && self.spec.name != '' ? self.spec.name : self.metadata.name) in ['mysql', 'sys', 'information_schema', 'performance_schema'])",message="the schema belongs to the server"
+
+// GreatSQLDatabase is the Schema for the greatsqldatabases API
+type GreatSQLDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GreatSQLDatabaseSpec   `json:"spec,omitempty"`
+	Status GreatSQLDatabaseStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GreatSQLDatabaseList contains a list of GreatSQLDatabase
+type GreatSQLDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GreatSQLDatabase `json:"items"`
+}
+
+// GetDatabaseName returns the name of the schema
+func (d *GreatSQLDatabase) GetDatabaseName() string {
+	if d.Spec.Name != "" {
+		return d.Spec.Name
+	}
+	return d.Name
+}
+
+func (d *GreatSQLDatabase) Finalizer() string {
+	return "finalizer.greatsqldatabase.greatsql.cn"
+}
+
+func init() {
+	SchemeBuilder.Register(&GreatSQLDatabase{}, &GreatSQLDatabaseList{})
+}
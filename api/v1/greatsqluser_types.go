@@ -0,0 +1,167 @@
+/*
+Copyright 2024 greatsql.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-22 09:48:31
+ * @file: greatsqluser_types.go
+ * @description: greatsql user types
+ */
+
+// GreatSQLUserSpec defines the desired state of GreatSQLUser
+type GreatSQLUserSpec struct {
+	// SingleRef is the name of the Single in the same namespace the account is created on
+	SingleRef string `json:"singleRef"`
+	// Username of the account, the name of the GreatSQLUser if empty. root, mysql.*
+	// and the accounts of the operator are reserved. An account that exists but
+	// was not created by this GreatSQLUser is not taken over.
+	//+kubebuilder:validation:MaxLength=32
+	//+optional
+	Username string `json:"username,omitempty"`
+	// Hosts the account may connect from, % if empty
+	//+optional
+	Hosts []string `json:"hosts,omitempty"`
+	// PasswordSecret holds the password of the account. Without it a password is
+	// generated into the <name>-credentials secret.
+	//+optional
+	PasswordSecret *corev1.SecretKeySelector `json:"passwordSecret,omitempty"`
+	// AuthenticationPlugin such as caching_sha2_password, the server default if empty
+	//+kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	//+optional
+	AuthenticationPlugin string `json:"authenticationPlugin,omitempty"`
+	// Resources limit the account, 0 is unlimited
+	//+optional
+	Resources UserResources `json:"resources,omitempty"`
+	// Grants of the account, a grant removed from the list is revoked
+	//+optional
+	Grants []Grant `json:"grants,omitempty"`
+}
+
+// UserResources defines the resource limits of an account
+type UserResources struct {
+	//+kubebuilder:validation:Minimum=0
+	MaxQueriesPerHour int32 `json:"maxQueriesPerHour,omitempty"`
+	//+kubebuilder:validation:Minimum=0
+	MaxUpdatesPerHour int32 `json:"maxUpdatesPerHour,omitempty"`
+	//+kubebuilder:validation:Minimum=0
+	MaxConnectionsPerHour int32 `json:"maxConnectionsPerHour,omitempty"`
+	//+kubebuilder:validation:Minimum=0
+	MaxUserConnections int32 `json:"maxUserConnections,omitempty"`
+}
+
+//+kubebuilder:validation:XValidation:rule="self.database != 'mysql'",message="privileges on the mysql schema can not be granted"
+//+kubebuilder:validation:XValidation:rule="self.database != '*' || (!(has(self.withGrantOption) && self.withGrantOption) && self.privileges.all(p, p.upperAscii() in ['PROCESS', 'REPLICATION CLIENT', 'SHOW DATABASES']))",message="only PROCESS, REPLICATION CLIENT and SHOW DATABASES can be granted globally, without grant option"
+
+// Grant defines privileges of an account on a database or table. The mysql schema
+// is off limits, global grants are limited to privileges observing the server.
+type Grant struct {
+	// Privileges such as SELECT, INSERT or ALL PRIVILEGES
+	//+kubebuilder:validation:MinItems=1
+	Privileges []Privilege `json:"privileges"`
+	// Database the privileges apply to, * for every database
+	Database string `json:"database"`
+	// Table of the database the privileges apply to, * for every table
+	//+kubebuilder:default="*"
+	//+optional
+	Table string `json:"table,omitempty"`
+	// WithGrantOption lets the account grant the privileges to others
+	//+optional
+	WithGrantOption bool `json:"withGrantOption,omitempty"`
+}
+
+//+kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z_ ]*$`
+
+// Privilege is a static or dynamic privilege name
+type Privilege string
+
+// GreatSQLUserStatus defines the observed state of GreatSQLUser
+type GreatSQLUserStatus struct {
+	Username string   `json:"username,omitempty"` // username the accounts were created with
+	Hosts    []string `json:"hosts,omitempty"`    // hosts the accounts were created for
+	Grants   []Grant  `json:"grants,omitempty"`   // grants given to the accounts
+	// PasswordVersion is the resourceVersion of the password secret last applied
+	PasswordVersion    string `json:"passwordVersion,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Condition types of the GreatSQLUser and GreatSQLDatabase
+const (
+	// ConditionReady is true once the objects were created on the Single as
+	// specified. On a Single it is true while servers take clients, not before
+	// spec.initScripts ran.
+	ConditionReady = "Ready"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Single",type="string",JSONPath=".spec.singleRef",description="The single the account is created on"
+//+kubebuilder:printcolumn:name="Username",type="string",JSONPath=".status.username",description="The username of the account"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the account is in sync"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:validation:XValidation:rule="[has(self.spec.username) && self.spec.username != '' ? self.spec.username : self.metadata.name].all(u, !(u in ['root', 'greatsql_repl']) && !u.startsWith('greatsql_clone_') && !u.startsWith('mysql.'))",message="the username is reserved for the server or the operator"
+
+// GreatSQLUser is the Schema for the greatsqlusers API
+type GreatSQLUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GreatSQLUserSpec   `json:"spec,omitempty"`
+	Status GreatSQLUserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GreatSQLUserList contains a list of GreatSQLUser
+type GreatSQLUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GreatSQLUser `json:"items"`
+}
+
+// GetUsername returns the username of the account
+func (u *GreatSQLUser) GetUsername() string {
+	if u.Spec.Username != "" {
+		return u.Spec.Username
+	}
+	return u.Name
+}
+
+// GetHosts returns the hosts the account may connect from
+func (u *GreatSQLUser) GetHosts() []string {
+	if len(u.Spec.Hosts) > 0 {
+		return u.Spec.Hosts
+	}
+	return []string{"%"}
+}
+
+func (u *GreatSQLUser) Finalizer() string {
+	return "finalizer.greatsqluser.greatsql.cn"
+}
+
+func init() {
+	SchemeBuilder.Register(&GreatSQLUser{}, &GreatSQLUserList{})
+}
@@ -33,16 +33,51 @@ import (
 type SingleSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	//+kubebuilder:validation:Enum=single;replicaofGroupCluster;singlePrimaryGroupCluster;multiPrimaryGroupCluster
-	GreatSqlType   GreatSqlType                  `json:"greatSqlType,omitempty"`
-	Role           MemberRole                    `json:"role,omitempty"`
-	Size           *int32                        `json:"size,omitempty"`
-	PodSpec        PodSpec                       `json:"podSpec,omitempty"`
-	Ports          []corev1.ServicePort          `json:"ports,omitempty"`
-	Type           corev1.ServiceType            `json:"type,omitempty"`
-	DnsPolicy      corev1.DNSPolicy              `json:"dnsPolicy,omitempty"`
+	//+kubebuilder:validation:Enum=single;replicaofCluster;singlePrimaryGroupCluster;multiPrimaryGroupCluster
+	GreatSqlType GreatSqlType         `json:"greatSqlType,omitempty"`
+	Role         MemberRole           `json:"role,omitempty"`
+	Size         *int32               `json:"size,omitempty"`
+	PodSpec      PodSpec              `json:"podSpec,omitempty"`
+	Ports        []corev1.ServicePort `json:"ports,omitempty"`
+	Type         corev1.ServiceType   `json:"type,omitempty"`
+	DnsPolicy    corev1.DNSPolicy     `json:"dnsPolicy,omitempty"`
+	//+kubebuilder:default={}
 	UpgradeOptions UpgradeOptions                `json:"upgradeOptions,omitempty"`
 	UpdateStrategy appsv1.DeploymentStrategyType `json:"updateStrategy,omitempty"`
+	//+kubebuilder:default={}
+	Failover FailoverSpec `json:"failover,omitempty"`
+	Scaling  ScalingSpec  `json:"scaling,omitempty"`
+	// DataSource seeds the data of a new single, it is only used once
+	//+optional
+	DataSource *DataSource `json:"dataSource,omitempty"`
+	// Standby keeps the single replicating from a primary instance in another
+	// namespace or kubernetes cluster until the promote annotation detaches it
+	//+optional
+	Standby *StandbySpec `json:"standby,omitempty"`
+	// DelayedReplica keeps replicas of a replicaofCluster behind the primary
+	//+optional
+	DelayedReplica *DelayedReplicaSpec `json:"delayedReplica,omitempty"`
+	// MaintenanceWindows restrict restarts, upgrades and statefulset recreation
+	// to the given time ranges, they are applied right away if empty
+	//+optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// Paused stops the operator from changing the instance, its status is still observed
+	//+optional
+	Paused bool `json:"paused,omitempty"`
+	// Stopped shuts the servers down and keeps their volumes, unsetting it starts them again
+	//+optional
+	Stopped bool `json:"stopped,omitempty"`
+	// InitScripts run once on the primary after the datadir is initialized, the
+	// Ready condition and status.ready stay false and 0 until they ran. They are
+	// only run for an instance created with them, scripts added later are rejected.
+	//+optional
+	InitScripts []InitScript `json:"initScripts,omitempty"`
+	// HealthCheck configures the default probes, probes set in podSpec replace them
+	//+kubebuilder:default={}
+	HealthCheck HealthCheckSpec `json:"healthCheck,omitempty"`
+	// TLS encrypts client connections and the traffic between members with an operator managed certificate
+	//+optional
+	TLS *TLSSpec `json:"tls,omitempty"`
 }
 
 // GetSize returns the size of the single
@@ -53,19 +88,132 @@ func (s *SingleSpec) GetSize() int32 {
 	return 1
 }
 
+// IsGroupReplication returns true for the MGR topologies
+func (s *SingleSpec) IsGroupReplication() bool {
+	return s.GreatSqlType == GreatSqlTypeSinglePrimaryGroupCluster || s.GreatSqlType == GreatSqlTypeMultiPrimaryGroupCluster
+}
+
+// IsCluster returns true for the topologies backed by a statefulset
+func (s *SingleSpec) IsCluster() bool {
+	return s.GreatSqlType == GreatSqlTypeReplicaofCluster || s.IsGroupReplication()
+}
+
+// NeedsPersistentVolumeClaim returns true if the operator creates the data claim
+// from the storage template, i.e. no volumeSpec data volume is set
+func (s *SingleSpec) NeedsPersistentVolumeClaim() bool {
+	v := s.PodSpec.VolumeSpec
+	return v == nil || (v.PersistentVolumeClaim == nil && v.HostPath == nil && v.EmptyDir == nil)
+}
+
+// RestoresFromDataSource returns true if the data claims are populated from a
+// snapshot or another claim instead of being initialized empty. The restored
+// data keeps the accounts of its source, MYSQL_ROOT_PASSWORD has to be the root
+// password of the source.
+func (s *SingleSpec) RestoresFromDataSource() bool {
+	storage := s.PodSpec.Storage
+	if !s.NeedsPersistentVolumeClaim() || storage == nil || storage.PersistentVolumeClaimTemplate == nil {
+		return false
+	}
+	return storage.PersistentVolumeClaimTemplate.DataSource != nil || storage.PersistentVolumeClaimTemplate.DataSourceRef != nil
+}
+
+// IsStandby returns true if the single replicates from a primary instance
+func (s *SingleSpec) IsStandby() bool {
+	return s.Standby != nil
+}
+
+// IsEphemeral returns true if the data volume is an emptyDir, the data is lost with the pod
+func (s *SingleSpec) IsEphemeral() bool {
+	v := s.PodSpec.VolumeSpec
+	return v != nil && v.PersistentVolumeClaim == nil && v.HostPath == nil && v.EmptyDir != nil
+}
+
+// IsHostPath returns true if the data volume is a hostPath, the data stays on one node
+func (s *SingleSpec) IsHostPath() bool {
+	v := s.PodSpec.VolumeSpec
+	return v != nil && v.PersistentVolumeClaim == nil && v.HostPath != nil
+}
+
+// IsPinnedToNode returns true if a nodeSelector or a required node affinity keeps
+// the pods on the nodes they were scheduled to
+func (s *SingleSpec) IsPinnedToNode() bool {
+	if len(s.PodSpec.NodeSelector) > 0 {
+		return true
+	}
+	affinity := s.PodSpec.Affinity
+	return affinity != nil && affinity.Advanced != nil && affinity.Advanced.NodeAffinity != nil &&
+		affinity.Advanced.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil
+}
+
 // SingleStatus defines the observed state of Single
 type SingleStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	AccessPoint             string `json:"accessPoint,omitempty"`
-	Size                    int32  `json:"size,omitempty"`
-	Ready                   int32  `json:"ready,omitempty"`
-	Age                     string `json:"age,omitempty"`
-	appsv1.DeploymentStatus `json:",inline"`
+	AccessPoint string         `json:"accessPoint,omitempty"`
+	Size        int32          `json:"size,omitempty"`
+	Ready       int32          `json:"ready,omitempty"`
+	Age         string         `json:"age,omitempty"`
+	Selector    string         `json:"selector,omitempty"` // label selector of the pods, used by the scale subresource
+	Primary     string         `json:"primary,omitempty"`  // pod currently serving writes
+	Members     []MemberStatus `json:"members,omitempty"`
+	Volumes     []VolumeUsage  `json:"volumes,omitempty"` // data volume usage of every pod
+	Standby     *StandbyStatus `json:"standby,omitempty"` // replication from the primary instance
+	Version     *VersionStatus `json:"version,omitempty"` // running and available versions
+	// InitScripts records the scripts of spec.initScripts that were run. It is
+	// set when the instance is created with scripts, they only run on that new datadir.
+	InitScripts *InitScriptsStatus `json:"initScripts,omitempty"`
+	TLS         *TLSStatus         `json:"tls,omitempty"` // server certificate in use
+
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// Condition types of the Single
+const (
+	// ConditionPrimaryHealthy is false while the primary fails its health checks
+	ConditionPrimaryHealthy = "PrimaryHealthy"
+	// ConditionSwitchover reports the outcome of the last switchover, failover or primary election request
+	ConditionSwitchover = "Switchover"
+	// ConditionGroupOnline is false while no MGR member is ONLINE
+	ConditionGroupOnline = "GroupOnline"
+	// ConditionScaling reports the progress of removing members
+	ConditionScaling = "Scaling"
+	// ConditionVolumeExpansion reports the progress of growing the data volumes
+	ConditionVolumeExpansion = "VolumeExpansion"
+	// ConditionStorageCeiling is true while a data volume is above the threshold at the maximum size
+	ConditionStorageCeiling = "StorageCeiling"
+	// ConditionSnapshotBackup reports the outcome of the last volume snapshot backup
+	ConditionSnapshotBackup = "SnapshotBackup"
+	// ConditionCloned reports the progress of seeding the data from dataSource.cloneFrom
+	ConditionCloned = "Cloned"
+	// ConditionImport reports the progress of importing dataSource.external
+	ConditionImport = "Import"
+	// ConditionRestored reports whether root takes MYSQL_ROOT_PASSWORD on data restored from a claim dataSource
+	ConditionRestored = "Restored"
+	// ConditionStandby reports the replication from the primary instance of a standby
+	ConditionStandby = "Standby"
+	// ConditionCatchUp reports the progress of rolling a delayed replica forward
+	ConditionCatchUp = "CatchUp"
+	// ConditionUpgrade reports the checks and the rollout of a new image or template
+	ConditionUpgrade = "Upgrade"
+	// ConditionPendingRestart is true while disruptive changes wait for a maintenance window
+	ConditionPendingRestart = "PendingRestart"
+	// ConditionPaused is true while the operator leaves the instance alone
+	ConditionPaused = "Paused"
+	// ConditionStopped is true once every server of a stopped instance is shut down
+	ConditionStopped = "Stopped"
+	// ConditionInitScripts reports the execution of spec.initScripts
+	ConditionInitScripts = "InitScripts"
+	// ConditionEphemeralStorage is true while the data volume is an emptyDir, the data is lost with the pod
+	ConditionEphemeralStorage = "EphemeralStorage"
+	// ConditionTLS reports the issuance of the server certificate and its reload on the members
+	ConditionTLS = "TLS"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.size,statuspath=.status.size,selectorpath=.status.selector
 //+kubebuilder:printcolumn:name="AccessPoint",type="string",JSONPath=".status.accessPoint",description="The access point of the single"
 //+kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".spec.size",description="The size of the single"
 //+kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.ready",description="The ready of the single"
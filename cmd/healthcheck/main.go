@@ -0,0 +1,125 @@
+/*
+Copyright 2024 greatsql.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/healthcheck"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-23 15:03:44
+ * @file: main.go
+ * @description: health check binary run by the probes of the greatsql container
+ */
+
+const (
+	// checkTimeout stays below the timeoutSeconds of the default probes
+	checkTimeout = 8 * time.Second
+	// rootPasswordEnv is the env of the greatsql container holding the root password
+	rootPasswordEnv = "MYSQL_ROOT_PASSWORD"
+)
+
+func main() {
+	if len(os.Args) != 2 && !(len(os.Args) == 3 && os.Args[1] == "install") {
+		fmt.Fprintln(os.Stderr, "usage: greatsql-healthcheck startup|liveness|readiness | install <dir>")
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "install":
+		err = install(os.Args[2])
+	case string(healthcheck.Startup), string(healthcheck.Liveness), string(healthcheck.Readiness):
+		err = check(healthcheck.Probe(os.Args[1]))
+	default:
+		err = fmt.Errorf("unknown probe %s", os.Args[1])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// check runs the checks of probe against the local server
+func check(probe healthcheck.Probe) error {
+	opts := healthcheck.Options{
+		Type:    singlev1.GreatSqlType(os.Getenv(healthcheck.EnvType)),
+		Standby: os.Getenv(healthcheck.EnvStandby) == "true",
+		DataDir: os.Getenv(healthcheck.EnvDataDir),
+	}
+	if lag := os.Getenv(healthcheck.EnvMaxLagSeconds); lag != "" {
+		seconds, err := strconv.ParseInt(lag, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", healthcheck.EnvMaxLagSeconds, err)
+		}
+		opts.MaxLagSeconds = seconds
+	}
+	// the role label changes with failovers, it is read from the downward api
+	if file := os.Getenv(healthcheck.EnvLabelsFile); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		opts.Role = singlev1.MemberRole(healthcheck.ParseLabels(string(data))[consts.GreatSqlRole])
+	}
+
+	db, err := greatsql.NewClient("127.0.0.1", greatsql.DefaultPort, greatsql.RootUser, os.Getenv(rootPasswordEnv))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	return healthcheck.Check(ctx, db, probe, opts)
+}
+
+// install copies the binary into dir, the init container shares it with the
+// greatsql container through an emptyDir
+func install(dir string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(filepath.Join(dir, filepath.Base(self)), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -3,6 +3,7 @@ module github.com/keington/greatsql-operator
 go 1.21.0
 
 require (
+	github.com/go-sql-driver/mysql v1.7.1
 	github.com/onsi/ginkgo/v2 v2.14.0
 	github.com/onsi/gomega v1.30.0
 	k8s.io/apimachinery v0.29.0
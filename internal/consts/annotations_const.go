@@ -9,7 +9,28 @@ package consts
 
 // Annotations const
 const (
-	// cm hash
+	// hash of the generated my.cnf on the pod template, a changed my.cnf restarts the pods
 	ConfigMapDataHash string = "greatsql.cn/configmap-data-hash"
 	//UpdateOnChangeAnnotation  string = "greatsql.cn/update-on-change"
+	// pod name to promote in a planned switchover, for MGR the member passed to group_replication_set_as_primary()
+	SwitchoverTarget string = "greatsql.cn/switchover-target"
+	// pod name to bootstrap a fully stopped MGR group from when the operator
+	// can not prove it is the most advanced member, "true" accepts its own choice
+	ForceBootstrap string = "greatsql.cn/force-bootstrap"
+	// name of the volumeSnapshot to take of the data volume, "true" generates one
+	SnapshotBackup string = "greatsql.cn/snapshot-backup"
+	// promotes a single importing an external server and stops replicating from it
+	Cutover string = "greatsql.cn/cutover"
+	// makes a standby writable and stops replicating from its primary instance
+	PromoteStandby string = "greatsql.cn/promote-standby"
+	// <pod>=<gtid set|RFC3339 time> rolls a delayed replica forward to just before
+	// the gtids or the time and holds it there until the annotation is removed
+	CatchUp string = "greatsql.cn/catch-up"
+	// applies the changes waiting for a maintenance window right away, removed once they are applied
+	ApplyPendingChanges string = "greatsql.cn/apply-pending-changes"
+	// comma separated namespaces whose singles may clone this one with dataSource.cloneFrom,
+	// "*" for every namespace. Singles of the same namespace always may.
+	CloneAllowedNamespaces string = "greatsql.cn/clone-allowed-namespaces"
+	// hash of the pod template the operator generated, changes to it restart the pods
+	TemplateHash string = "greatsql.cn/template-hash"
 )
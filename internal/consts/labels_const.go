@@ -11,4 +11,5 @@ package consts
 const (
 	AppKubernetesComponent string = "app.kubernetes.io/component"
 	AppKubernetesName      string = "app.kubernetes.io/name"
+	GreatSqlRole           string = "greatsql.cn/role"
 )
@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 17:10:18
+ * @file: clone_from.go
+ * @description: seed a new single from a running one with the clone plugin
+ */
+
+var (
+	// cloning holds the singles a clone from dataSource.cloneFrom runs for, keyed by namespace/name
+	cloning sync.Map
+	// cloneFailures holds the error of the last failed clone, keyed by namespace/name
+	cloneFailures sync.Map
+)
+
+// reconcileCloneFrom seeds the single from dataSource.cloneFrom once. A temporary
+// account is created on the source for the clone, it is dropped afterwards or kept
+// as replication account when the single replicates from the source. It returns
+// true while the clone is in progress.
+func (r *SingleReconciler) reconcileCloneFrom(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	dataSource := singleGreatsql.Spec.DataSource
+	if dataSource == nil || dataSource.CloneFrom == nil || meta.IsStatusConditionTrue(singleGreatsql.Status.Conditions, singlev1.ConditionCloned) {
+		return false, nil
+	}
+
+	key := singleGreatsql.Namespace + "/" + singleGreatsql.Name
+	if _, running := cloning.Load(key); running {
+		return true, nil
+	}
+	if err, failed := cloneFailures.LoadAndDelete(key); failed {
+		setCondition(singleGreatsql, singlev1.ConditionCloned, metav1.ConditionFalse, "CloneFailed", err.(error).Error())
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "CloneFailed", "clone failed, retrying: %v", err)
+	}
+
+	source, err := r.cloneSource(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	// the clone copies the data and the root password of the source, another
+	// namespace has to be let in by the source
+	if !cloneAllowed(source, singleGreatsql.Namespace) {
+		message := fmt.Sprintf("%s/%s does not allow clones from namespace %s, see the %s annotation", source.Namespace, source.Name, singleGreatsql.Namespace, consts.CloneAllowedNamespaces)
+		if condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionCloned); condition == nil || condition.Reason != "CloneNotAllowed" {
+			r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, "CloneNotAllowed", message)
+		}
+		setCondition(singleGreatsql, singlev1.ConditionCloned, metav1.ConditionFalse, "CloneNotAllowed", message)
+		return true, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	pod, err := r.runningPod(ctx, singleGreatsql)
+	if err != nil || pod == nil {
+		return true, err
+	}
+
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	sourcePassword, err := r.rootPassword(ctx, source)
+	if err != nil {
+		return true, err
+	}
+	if err := r.ensureInternalSecret(ctx, singleGreatsql); err != nil {
+		return true, err
+	}
+	cloneUserPassword, err := r.internalPassword(ctx, singleGreatsql, replicationPasswordKey)
+	if err != nil {
+		return true, err
+	}
+
+	// once the clone landed the single runs with the accounts copied from the source
+	db, err := connectWithAny(ctx, pod.Status.PodIP, password, sourcePassword)
+	if err != nil {
+		log.Info("Waiting for the single to accept connections", "error", err.Error())
+		return true, nil
+	}
+	defer db.Close()
+
+	user := cloneUser(singleGreatsql)
+	cloned, err := db.UserExists(ctx, user)
+	if err != nil {
+		return true, err
+	}
+	if cloned {
+		return false, r.finishCloneFrom(ctx, singleGreatsql, source, db, password, sourcePassword, cloneUserPassword)
+	}
+
+	host, port := kube.ServiceAddress(source)
+	donor, err := greatsql.NewClient(host, port, greatsql.RootUser, sourcePassword)
+	if err != nil {
+		return true, err
+	}
+	defer donor.Close()
+	if err := donor.InstallClonePlugin(ctx); err != nil {
+		return true, err
+	}
+	if err := donor.EnsureReplicationUser(ctx, user, cloneUserPassword, false); err != nil {
+		return true, err
+	}
+
+	cloning.Store(key, struct{}{})
+	podIP := pod.Status.PodIP
+	go func() {
+		defer cloning.Delete(key)
+
+		recipient, err := greatsql.NewClient(podIP, greatsql.DefaultPort, greatsql.RootUser, password)
+		if err == nil {
+			defer recipient.Close()
+			log.Info("Cloning source into single", "source", host)
+			err = recipient.Clone(context.Background(), greatsql.ReplicationSource{Host: host, Port: port, User: user, Password: cloneUserPassword})
+		}
+		if err != nil {
+			log.Error(err, "Could not clone source into single")
+			cloneFailures.Store(key, err)
+			return
+		}
+		log.Info("Clone finished, single restarts with the source data")
+	}()
+
+	setCondition(singleGreatsql, singlev1.ConditionCloned, metav1.ConditionFalse, "Cloning", "cloning "+source.Namespace+"/"+source.Name)
+	return true, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// finishCloneFrom restores the root password of the single, then either points
+// it at the source or drops the clone account
+func (r *SingleReconciler) finishCloneFrom(ctx context.Context, singleGreatsql, source *singlev1.Single, db *greatsql.Client, password, sourcePassword, cloneUserPassword string) error {
+	if err := db.SetRootPassword(ctx, password); err != nil {
+		return err
+	}
+
+	user := cloneUser(singleGreatsql)
+	host, port := kube.ServiceAddress(source)
+	message := "cloned from " + source.Namespace + "/" + source.Name
+	if singleGreatsql.Spec.DataSource.CloneFrom.Replicate {
+		// the configMap gives every single the same server_id, a replica needs its own
+		if err := db.SetServerID(ctx, serverIDFor(singleGreatsql)); err != nil {
+			return err
+		}
+		if err := db.SetReadOnly(ctx, true); err != nil {
+			return err
+		}
+		if err := db.ChangeReplicationSource(ctx, greatsql.ReplicationSource{Host: host, Port: port, User: user, Password: cloneUserPassword}); err != nil {
+			return err
+		}
+		if err := db.StartReplica(ctx); err != nil {
+			return err
+		}
+		message += ", replicating from it"
+	} else {
+		if err := db.DropUser(ctx, user); err != nil {
+			return err
+		}
+		donor, err := greatsql.NewClient(host, port, greatsql.RootUser, sourcePassword)
+		if err != nil {
+			return err
+		}
+		defer donor.Close()
+		if err := donor.DropUser(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Clone completed", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "source", source.Name)
+	setCondition(singleGreatsql, singlev1.ConditionCloned, metav1.ConditionTrue, "Cloned", message)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Cloned", message)
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// cloneSource returns the single referenced by dataSource.cloneFrom
+func (r *SingleReconciler) cloneSource(ctx context.Context, singleGreatsql *singlev1.Single) (*singlev1.Single, error) {
+	cloneFrom := singleGreatsql.Spec.DataSource.CloneFrom
+	namespace := cloneFrom.Namespace
+	if namespace == "" {
+		namespace = singleGreatsql.Namespace
+	}
+
+	source := &singlev1.Single{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: cloneFrom.Name}, source); err != nil {
+		return nil, fmt.Errorf("clone source %s/%s: %w", namespace, cloneFrom.Name, err)
+	}
+	return source, nil
+}
+
+// cloneAllowed returns true if singles of namespace may clone source
+func cloneAllowed(source *singlev1.Single, namespace string) bool {
+	if source.Namespace == namespace {
+		return true
+	}
+	allowed := strings.Split(source.Annotations[consts.CloneAllowedNamespaces], ",")
+	for i := range allowed {
+		allowed[i] = strings.TrimSpace(allowed[i])
+	}
+	return slices.Contains(allowed, "*") || slices.Contains(allowed, namespace)
+}
+
+// readyPod returns a ready pod of the single, nil if none is ready
+func (r *SingleReconciler) readyPod(ctx context.Context, singleGreatsql *singlev1.Single) (*corev1.Pod, error) {
+	return r.findPod(ctx, singleGreatsql, podReady)
+}
+
+// runningPod returns a running pod of the single even if it is not ready. After
+// a clone the probes may not log in until the operator reset the root password.
+func (r *SingleReconciler) runningPod(ctx context.Context, singleGreatsql *singlev1.Single) (*corev1.Pod, error) {
+	return r.findPod(ctx, singleGreatsql, func(pod *corev1.Pod) bool {
+		return pod.Status.Phase == corev1.PodRunning
+	})
+}
+
+// findPod returns the first pod of the single that matches, nil if none does
+func (r *SingleReconciler) findPod(ctx context.Context, singleGreatsql *singlev1.Single, match func(*corev1.Pod) bool) (*corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if pod := &pods.Items[i]; pod.Status.PodIP != "" && pod.DeletionTimestamp == nil && match(pod) {
+			return pod, nil
+		}
+	}
+	return nil, nil
+}
+
+// connectWithAny connects as root with the first password the server accepts
+func connectWithAny(ctx context.Context, host string, passwords ...string) (*greatsql.Client, error) {
+	var err error
+	for _, password := range passwords {
+		var db *greatsql.Client
+		if db, err = greatsql.NewClient(host, greatsql.DefaultPort, greatsql.RootUser, password); err != nil {
+			continue
+		}
+		if err = db.Ping(ctx); err == nil {
+			return db, nil
+		}
+		db.Close()
+	}
+	return nil, err
+}
+
+// cloneUser returns the temporary account the single clones its source with
+func cloneUser(singleGreatsql *singlev1.Single) string {
+	uid := string(singleGreatsql.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return cloneUserPrefix + uid
+}
+
+// serverIDFor derives a server_id from the uid, it only has to differ from the source
+func serverIDFor(singleGreatsql *singlev1.Single) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(singleGreatsql.UID))
+	return int32(h.Sum32()&0x7fffffff) | 1<<16
+}
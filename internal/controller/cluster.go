@@ -0,0 +1,315 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+	"github.com/keington/greatsql-operator/internal/utils"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 11:20:41
+ * @file: cluster.go
+ * @description: statefulset backed topologies
+ */
+
+const (
+	// clusterRequeueInterval is how often the members are health checked
+	clusterRequeueInterval = 10 * time.Second
+
+	// replicationUser is the account replicas pull binlogs with
+	replicationUser = "greatsql_repl"
+	// cloneUserPrefix starts the temporary accounts a single clones its source with
+	cloneUserPrefix = "greatsql_clone_"
+	// replicationPasswordKey is the key of its password in the internal secret
+	replicationPasswordKey = "replication"
+
+	rootPasswordEnv = "MYSQL_ROOT_PASSWORD"
+)
+
+// reconcileCluster reconciles the topologies backed by a statefulset
+func (r *SingleReconciler) reconcileCluster(ctx context.Context, req ctrl.Request, singleGreatsql *singlev1.Single) (ctrl.Result, error) {
+	log := logger.WithValues("Request.Service.Namespace", req.Namespace, "Request.Service.Name", req.Name)
+
+	if err := r.ensureClusterResources(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile cluster resources")
+		return ctrl.Result{}, err
+	}
+
+	// a stopped cluster has no members to reconcile
+	stopped, err := r.reconcileStopped(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not reconcile stopped instance")
+		return ctrl.Result{}, err
+	}
+	if stopped {
+		return ctrl.Result{RequeueAfter: clusterRequeueInterval}, nil
+	}
+
+	reconcileTopology := r.reconcileReplication
+	if singleGreatsql.Spec.IsGroupReplication() {
+		reconcileTopology = r.reconcileGroupReplication
+	}
+	if err := reconcileTopology(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile replication")
+		return ctrl.Result{}, err
+	}
+	requeueAfter := clusterRequeueInterval
+	if catchUpPolling(singleGreatsql) {
+		requeueAfter = applierPollInterval
+	}
+	if _, err := r.reconcileInitScripts(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not run init scripts")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileSnapshotBackup(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile snapshot backup")
+		return ctrl.Result{}, err
+	}
+
+	if singleGreatsql.Spec.NeedsPersistentVolumeClaim() {
+		claims := &corev1.PersistentVolumeClaimList{}
+		if err := r.Client.List(ctx, claims, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+			return ctrl.Result{}, err
+		}
+		// only the data claims follow the storage request, the additional claims are sized on their own
+		var dataClaims []corev1.PersistentVolumeClaim
+		for _, pvc := range claims.Items {
+			if strings.HasPrefix(pvc.Name, kube.DataVolumeName(singleGreatsql)+"-") {
+				dataClaims = append(dataClaims, pvc)
+			}
+		}
+		pending, err := r.reconcileVolumeExpansion(ctx, singleGreatsql, dataClaims)
+		if err != nil {
+			log.Error(err, "Could not expand volumes")
+			return ctrl.Result{}, err
+		}
+		if pending {
+			return ctrl.Result{RequeueAfter: min(requeueAfter, volumeExpansionInterval)}, nil
+		}
+	}
+
+	if err := r.reconcileStorage(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile storage usage")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileVersion(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile version")
+		return ctrl.Result{}, err
+	}
+
+	pending, err := r.pendingClusterChanges(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not list pending changes")
+		return ctrl.Result{}, err
+	}
+	if _, err := r.reconcilePendingRestart(ctx, singleGreatsql, pending); err != nil {
+		log.Error(err, "Could not reconcile pending restarts")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// ensureClusterResources creates the configMap, secret, services and statefulset
+// of the cluster, the statefulset and services are kept in sync with the spec
+func (r *SingleReconciler) ensureClusterResources(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	configMapName := singleGreatsql.Name + "-config"
+	configMap := kube.NewConfigMap(singleGreatsql, configMapName)
+	if err := r.createIfNotExists(ctx, configMap); err != nil {
+		return err
+	}
+
+	if err := r.ensureInternalSecret(ctx, singleGreatsql); err != nil {
+		return err
+	}
+
+	services := []*corev1.Service{
+		kube.NewHeadlessService(singleGreatsql),
+		kube.NewService(singleGreatsql),
+		kube.NewReadService(singleGreatsql),
+	}
+	for _, svc := range services {
+		oldService := &corev1.Service{}
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(svc), oldService)
+		if errors.IsNotFound(err) {
+			if err := r.Client.Create(ctx, svc); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// clusterIP is immutable, only the selector and ports follow the spec
+		oldService.Spec.Ports = svc.Spec.Ports
+		oldService.Spec.Selector = svc.Spec.Selector
+		if err := r.Client.Update(ctx, oldService); err != nil {
+			return err
+		}
+	}
+
+	statefulSet := kube.NewStatefulSet(singleGreatsql, configMapName)
+	oldStatefulSet := &appsv1.StatefulSet{}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(statefulSet), oldStatefulSet)
+	if errors.IsNotFound(err) {
+		// the init scripts run on the datadirs the new statefulset initializes. A
+		// statefulset recreated for a grown claim template adopts existing members.
+		if singleGreatsql.Status.InitScripts == nil && len(singleGreatsql.Spec.InitScripts) > 0 && len(singleGreatsql.Status.Members) == 0 {
+			singleGreatsql.Status.InitScripts = newInitScriptsStatus(singleGreatsql)
+			if err := r.Client.Status().Update(ctx, singleGreatsql); err != nil {
+				return err
+			}
+		}
+		return r.Client.Create(ctx, statefulSet)
+	}
+	if err != nil {
+		return err
+	}
+
+	// volumeClaimTemplates are immutable, a grown template needs a new statefulset.
+	// It is deleted orphaning the pods and claims, the next reconcile recreates it
+	// and adopts them. Not while scaling in, the new one would drop the departing pods,
+	// nor outside a maintenance window.
+	open := maintenanceWindowOpen(singleGreatsql, time.Now())
+	if claimTemplateGrown(oldStatefulSet, statefulSet) && *oldStatefulSet.Spec.Replicas <= *statefulSet.Spec.Replicas && open {
+		logger.Info("Recreating statefulset for the grown claim template", "Name", oldStatefulSet.Name)
+		return r.Client.Delete(ctx, oldStatefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+	}
+
+	// only the replicas and template follow the spec. Scale in is left to the
+	// topology, the members must leave it first, unless every member shuts down.
+	if *oldStatefulSet.Spec.Replicas < *statefulSet.Spec.Replicas || singleGreatsql.Spec.Stopped {
+		oldStatefulSet.Spec.Replicas = statefulSet.Spec.Replicas
+	}
+	// a changed template waits for a maintenance window, a statefulset without the
+	// hash predates the windows and takes it right away
+	hash := oldStatefulSet.Annotations[consts.TemplateHash]
+	if hash != "" && hash != statefulSet.Annotations[consts.TemplateHash] && !open {
+		statefulSet.Spec.Template = oldStatefulSet.Spec.Template
+		statefulSet.Annotations[consts.TemplateHash] = hash
+	}
+	// a new image is only rolled out once the upgrade checks passed
+	if current, image := templateImage(singleGreatsql, oldStatefulSet.Spec.Template), templateImage(singleGreatsql, statefulSet.Spec.Template); current != image {
+		allowed, err := r.upgradeGate(ctx, singleGreatsql, current, image)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			statefulSet.Spec.Template = oldStatefulSet.Spec.Template
+			statefulSet.Annotations[consts.TemplateHash] = hash
+		}
+	}
+	// the my.cnf is read on start, it changes with the template. It is updated
+	// first, the restarted pods have to mount the new one.
+	if statefulSet.Spec.Template.Annotations[consts.ConfigMapDataHash] == kube.ConfigDataHash(singleGreatsql) {
+		if err := r.updateConfigMap(ctx, configMap); err != nil {
+			return err
+		}
+	}
+	if oldStatefulSet.Annotations == nil {
+		oldStatefulSet.Annotations = map[string]string{}
+	}
+	oldStatefulSet.Annotations[consts.TemplateHash] = statefulSet.Annotations[consts.TemplateHash]
+	oldStatefulSet.Spec.Template = statefulSet.Spec.Template
+	oldStatefulSet.Spec.UpdateStrategy = statefulSet.Spec.UpdateStrategy
+	oldStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy = statefulSet.Spec.PersistentVolumeClaimRetentionPolicy
+	return r.Client.Update(ctx, oldStatefulSet)
+}
+
+// ensureInternalSecret creates the secret holding the generated credentials
+func (r *SingleReconciler) ensureInternalSecret(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.InternalSecretName(singleGreatsql)}, secret)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	password, err := utils.RandomPassword(24)
+	if err != nil {
+		return err
+	}
+	return r.Client.Create(ctx, kube.NewInternalSecret(singleGreatsql, map[string][]byte{
+		replicationPasswordKey: []byte(password),
+	}))
+}
+
+// internalPassword returns a generated password from the internal secret
+func (r *SingleReconciler) internalPassword(ctx context.Context, singleGreatsql *singlev1.Single, key string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.InternalSecretName(singleGreatsql)}, secret); err != nil {
+		return "", err
+	}
+
+	password, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s", key, secret.Name)
+	}
+	return string(password), nil
+}
+
+// rootPassword resolves the MYSQL_ROOT_PASSWORD env of the container, either
+// the literal value or the secret it references
+func (r *SingleReconciler) rootPassword(ctx context.Context, singleGreatsql *singlev1.Single) (string, error) {
+	for _, env := range singleGreatsql.Spec.PodSpec.Envs {
+		if env.Name != rootPasswordEnv {
+			continue
+		}
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+			return env.Value, nil
+		}
+
+		ref := env.ValueFrom.SecretKeyRef
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: ref.Name}, secret); err != nil {
+			return "", err
+		}
+		password, ok := secret.Data[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+		}
+		return string(password), nil
+	}
+
+	return "", fmt.Errorf("env %s is required", rootPasswordEnv)
+}
+
+// updateConfigMap sets the data of the existing configMap to the generated one
+func (r *SingleReconciler) updateConfigMap(ctx context.Context, configMap *corev1.ConfigMap) error {
+	oldConfigMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(configMap), oldConfigMap); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(oldConfigMap.Data, configMap.Data) {
+		return nil
+	}
+	oldConfigMap.Data = configMap.Data
+	return r.Client.Update(ctx, oldConfigMap)
+}
+
+// createIfNotExists creates the object unless it already exists
+func (r *SingleReconciler) createIfNotExists(ctx context.Context, obj client.Object) error {
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopyObject().(client.Object))
+	if errors.IsNotFound(err) {
+		return r.Client.Create(ctx, obj)
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 21:30:09
+ * @file: delayed.go
+ * @description: delayed replicas and rolling them forward
+ */
+
+const (
+	// applierPollInterval is how often the applier is checked while catching up to a time
+	applierPollInterval = 500 * time.Millisecond
+)
+
+// delayed returns true for the members spec.delayedReplica keeps behind, callers
+// skip the primary first
+func delayed(singleGreatsql *singlev1.Single, m *member) bool {
+	spec := singleGreatsql.Spec.DelayedReplica
+	if spec == nil {
+		return false
+	}
+	if len(spec.Ordinals) == 0 {
+		return m.ordinal > 0 && m.ordinal == singleGreatsql.Spec.GetSize()-1
+	}
+	for _, ordinal := range spec.Ordinals {
+		if ordinal == m.ordinal {
+			return true
+		}
+	}
+	return false
+}
+
+// replicationDelay returns the SOURCE_DELAY of a replica
+func replicationDelay(singleGreatsql *singlev1.Single, m *member) int32 {
+	if !delayed(singleGreatsql, m) {
+		return 0
+	}
+	return singleGreatsql.Spec.DelayedReplica.DelaySeconds
+}
+
+// catchUpRequest returns the member and the target of the catch-up annotation
+func catchUpRequest(singleGreatsql *singlev1.Single) (string, string) {
+	pod, target, _ := strings.Cut(singleGreatsql.Annotations[consts.CatchUp], "=")
+	return pod, target
+}
+
+// catchUpHeld returns true for the member the catch-up annotation names
+func catchUpHeld(singleGreatsql *singlev1.Single, m *member) bool {
+	pod, _ := catchUpRequest(singleGreatsql)
+	return pod == m.pod.Name
+}
+
+// reconcileCatchUp rolls a delayed replica forward to just before the target of
+// the catch-up annotation and holds it there, the applier stays stopped until
+// the annotation is removed. It returns true while the member is held.
+func (r *SingleReconciler) reconcileCatchUp(ctx context.Context, singleGreatsql *singlev1.Single, m *member) (bool, error) {
+	pod, target := catchUpRequest(singleGreatsql)
+	if pod != m.pod.Name {
+		return false, nil
+	}
+
+	progress := fmt.Sprintf("%s is catching up to %s", m.pod.Name, target)
+	done := fmt.Sprintf("%s caught up to %s", m.pod.Name, target)
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionCatchUp)
+	if condition != nil && condition.Message == done {
+		return true, nil
+	}
+	catchingUp := condition != nil && condition.Message == progress
+
+	if !delayed(singleGreatsql, m) || m.replica == nil {
+		setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "InvalidTarget", m.pod.Name+" is not a delayed replica")
+		return false, nil
+	}
+	if catchingUp && m.replica.LastSQLError != "" {
+		setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "CatchUpFailed", m.replica.LastSQLError)
+		return true, nil
+	}
+
+	// a time can only be reached through the delay, which moves with the clock,
+	// so it is re-anchored on every reconcile until the applier waits for it
+	if at, err := time.Parse(time.RFC3339, target); err == nil {
+		age := time.Since(at)
+		if !catchingUp && (age < 0 || age > time.Duration(singleGreatsql.Spec.DelayedReplica.DelaySeconds)*time.Second) {
+			setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "InvalidTarget", fmt.Sprintf("%s is not between the applied transactions of %s and now", target, m.pod.Name))
+			return false, nil
+		}
+		if catchingUp && m.replica.SQLRunning && m.replica.ApplierIdle() {
+			return true, r.holdCaughtUp(ctx, singleGreatsql, m, done)
+		}
+
+		if m.replica.SQLRunning {
+			if err := m.db.StopApplier(ctx); err != nil {
+				return true, err
+			}
+		}
+		if err := m.db.SetReplicationDelay(ctx, int32(math.Ceil(time.Since(at).Seconds()))); err != nil {
+			return true, err
+		}
+		if err := m.db.StartApplier(ctx); err != nil {
+			return true, err
+		}
+		// the next reconcile stops the applier once the backlog is applied, it
+		// comes after applierPollInterval as the target is overshot meanwhile
+		setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "CatchingUp", progress)
+		return true, nil
+	}
+
+	gtids, err := greatsql.ParseGTIDSet(target)
+	if err != nil || gtids.Count() == 0 {
+		setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "InvalidTarget", fmt.Sprintf("%q is neither a gtid set nor an RFC3339 time", target))
+		return false, nil
+	}
+	if catchingUp {
+		// the applier stops by itself at the gtids
+		if !m.replica.SQLRunning {
+			setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionTrue, "CaughtUp", done)
+		}
+		return true, nil
+	}
+	if m.gtid.Contains(gtids) {
+		setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "InvalidTarget", fmt.Sprintf("%s already applied %s", m.pod.Name, target))
+		return false, nil
+	}
+
+	if m.replica.SQLRunning {
+		if err := m.db.StopApplier(ctx); err != nil {
+			return true, err
+		}
+	}
+	if err := m.db.SetReplicationDelay(ctx, 0); err != nil {
+		return true, err
+	}
+	if err := m.db.StartApplierUntilBefore(ctx, gtids); err != nil {
+		return true, err
+	}
+	setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "CatchingUp", progress)
+	return true, nil
+}
+
+// catchUpPolling returns true while a delayed replica rolls forward to a time,
+// its applier is checked every applierPollInterval instead of every reconcile
+func catchUpPolling(singleGreatsql *singlev1.Single) bool {
+	_, target := catchUpRequest(singleGreatsql)
+	if _, err := time.Parse(time.RFC3339, target); err != nil {
+		return false
+	}
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionCatchUp)
+	return condition != nil && condition.Reason == "CatchingUp"
+}
+
+// holdCaughtUp stops the applier of a member that reached the catch-up target
+func (r *SingleReconciler) holdCaughtUp(ctx context.Context, singleGreatsql *singlev1.Single, m *member, done string) error {
+	if err := m.db.StopApplier(ctx); err != nil {
+		return err
+	}
+	logger.Info("Delayed replica caught up", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "member", m.pod.Name)
+	setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionTrue, "CaughtUp", done)
+	return nil
+}
+
+// resumeCatchUp records that a held member went back to its delay once the
+// catch-up annotation is removed, configureReplica restores the delay
+func resumeCatchUp(singleGreatsql *singlev1.Single) {
+	if singleGreatsql.Annotations[consts.CatchUp] != "" {
+		return
+	}
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionCatchUp)
+	if condition != nil && (condition.Reason == "CatchingUp" || condition.Reason == "CaughtUp") {
+		setCondition(singleGreatsql, singlev1.ConditionCatchUp, metav1.ConditionFalse, "Resumed", "catch-up annotation removed, the delayed replicas apply with their delay again")
+	}
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 11:20:05
+ * @file: expansion.go
+ * @description: online data volume expansion
+ */
+
+const (
+	// volumeExpansionInterval is the requeue interval while a resize is in progress
+	volumeExpansionInterval = 15 * time.Second
+)
+
+// expandVolumes grows the data claims to the requested storage size. It returns
+// true while a resize, including the filesystem resize on the node, is in progress.
+// The outcome is recorded in the VolumeExpansion condition, shrinks are rejected.
+func (r *SingleReconciler) expandVolumes(ctx context.Context, singleGreatsql *singlev1.Single, claims []corev1.PersistentVolumeClaim) (bool, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	desired := kube.StorageRequest(singleGreatsql)
+	pending := false
+	for i := range claims {
+		pvc := &claims[i]
+		requested := pvc.Spec.Resources.Requests.Storage()
+
+		switch desired.Cmp(*requested) {
+		case -1:
+			setCondition(singleGreatsql, singlev1.ConditionVolumeExpansion, metav1.ConditionFalse, "ShrinkRejected",
+				fmt.Sprintf("%s can not shrink from %s to %s", pvc.Name, requested.String(), desired.String()))
+			return false, nil
+		case 1:
+			expandable, err := r.allowsVolumeExpansion(ctx, pvc)
+			if err != nil {
+				return false, err
+			}
+			if !expandable {
+				setCondition(singleGreatsql, singlev1.ConditionVolumeExpansion, metav1.ConditionFalse, "NotExpandable",
+					fmt.Sprintf("the storageClass of %s does not allow volume expansion", pvc.Name))
+				return false, nil
+			}
+
+			patch := client.MergeFrom(pvc.DeepCopy())
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+			if err := r.Client.Patch(ctx, pvc, patch); err != nil {
+				setCondition(singleGreatsql, singlev1.ConditionVolumeExpansion, metav1.ConditionFalse, "ExpansionFailed", err.Error())
+				return false, err
+			}
+			log.Info("Expanding persistentVolumeClaim", "Name", pvc.Name, "from", requested.String(), "to", desired.String())
+			pending = true
+			continue
+		}
+
+		for _, status := range pvc.Status.AllocatedResourceStatuses {
+			if status == corev1.PersistentVolumeClaimControllerResizeFailed || status == corev1.PersistentVolumeClaimNodeResizeFailed {
+				setCondition(singleGreatsql, singlev1.ConditionVolumeExpansion, metav1.ConditionFalse, "ExpansionFailed",
+					fmt.Sprintf("resizing %s failed: %s", pvc.Name, status))
+				return false, nil
+			}
+		}
+
+		// a bound claim reports the new capacity once the filesystem has been grown
+		capacity := pvc.Status.Capacity.Storage()
+		if pvc.Status.Phase == corev1.ClaimBound && (capacity.Cmp(desired) < 0 || resizing(pvc)) {
+			pending = true
+		}
+	}
+
+	switch {
+	case pending:
+		setCondition(singleGreatsql, singlev1.ConditionVolumeExpansion, metav1.ConditionFalse, "Expanding", "expanding data volumes to "+desired.String())
+	case meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionVolumeExpansion) != nil:
+		setCondition(singleGreatsql, singlev1.ConditionVolumeExpansion, metav1.ConditionTrue, "Expanded", "data volumes are "+desired.String())
+	}
+	return pending, nil
+}
+
+// reconcileVolumeExpansion expands the given claims and persists the condition
+func (r *SingleReconciler) reconcileVolumeExpansion(ctx context.Context, singleGreatsql *singlev1.Single, claims []corev1.PersistentVolumeClaim) (bool, error) {
+	conditions := append([]metav1.Condition(nil), singleGreatsql.Status.Conditions...)
+	pending, err := r.expandVolumes(ctx, singleGreatsql, claims)
+	if equality.Semantic.DeepEqual(conditions, singleGreatsql.Status.Conditions) {
+		return pending, err
+	}
+	if updateErr := r.Client.Status().Update(ctx, singleGreatsql); updateErr != nil {
+		return pending, updateErr
+	}
+	return pending, err
+}
+
+// allowsVolumeExpansion returns true if the storageClass of the claim allows expansion
+func (r *SingleReconciler) allowsVolumeExpansion(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: *pvc.Spec.StorageClassName}, storageClass); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// resizing returns true while the volume or its filesystem is being resized
+func resizing(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, c := range pvc.Status.Conditions {
+		if (c.Type == corev1.PersistentVolumeClaimResizing || c.Type == corev1.PersistentVolumeClaimFileSystemResizePending) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// claimTemplateGrown returns true if the storage of the claim template has to grow,
+// the template is immutable so the statefulset is recreated for it
+func claimTemplateGrown(oldStatefulSet, statefulSet *appsv1.StatefulSet) bool {
+	if len(oldStatefulSet.Spec.VolumeClaimTemplates) == 0 || len(statefulSet.Spec.VolumeClaimTemplates) == 0 {
+		return false
+	}
+	current := oldStatefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage()
+	desired := statefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage()
+	return desired.Cmp(*current) > 0
+}
@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+	"github.com/keington/greatsql-operator/internal/utils"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-22 15:20:17
+ * @file: greatsqldatabase_controller.go
+ * @description: declarative schemas
+ */
+
+const (
+	// roles of the accounts generated for a schema
+	ownerRole    = "owner"
+	readonlyRole = "readonly"
+)
+
+// GreatSQLDatabaseReconciler reconciles a GreatSQLDatabase object
+type GreatSQLDatabaseReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+var (
+	databaseLogger = ctrl.Log.WithName("greatsql-database-controller")
+
+	// systemSchemas belong to the server, they are never created, granted or dropped
+	systemSchemas = []string{"mysql", "sys", "information_schema", "performance_schema"}
+
+	// errNotOwned is returned for a secret or GreatSQLUser of the generated names
+	// that another resource or a human created
+	errNotOwned = goerrors.New("exists and is not owned by this GreatSQLDatabase")
+)
+
+//+kubebuilder:rbac:groups=greatsql.greatsql.cn,resources=greatsqldatabases,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=greatsql.greatsql.cn,resources=greatsqldatabases/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=greatsql.greatsql.cn,resources=greatsqldatabases/finalizers,verbs=update
+
+// Reconcile creates the schema of a GreatSQLDatabase on the primary of its single
+// and the GreatSQLUsers of its generated accounts
+func (r *GreatSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := databaseLogger.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+
+	database := &singlev1.GreatSQLDatabase{}
+	if err := r.Client.Get(ctx, req.NamespacedName, database); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	single := &singlev1.Single{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: database.Namespace, Name: database.Spec.SingleRef}, single)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	singleFound := err == nil && single.DeletionTimestamp == nil
+
+	// the schema is only dropped when asked to, the generated accounts are
+	// garbage collected with their owner
+	if database.DeletionTimestamp != nil {
+		if database.Spec.DeletionPolicy == singlev1.DeletionPolicyDelete && singleFound && database.Status.Name != "" {
+			db, err := connectPrimary(ctx, r.Client, single)
+			if err != nil || db == nil {
+				log.Info("Waiting for the single to drop the schema", "single", single.Name)
+				return ctrl.Result{RequeueAfter: accountRetryInterval}, nil
+			}
+			defer db.Close()
+			if err := db.DropDatabase(ctx, database.Status.Name); err != nil {
+				log.Error(err, "Could not drop schema", "database", database.Status.Name)
+				return ctrl.Result{}, err
+			}
+			log.Info("Dropped schema", "database", database.Status.Name)
+		}
+		controllerutil.RemoveFinalizer(database, database.Finalizer())
+		return ctrl.Result{}, r.Client.Update(ctx, database)
+	}
+	if controllerutil.AddFinalizer(database, database.Finalizer()) {
+		if err := r.Client.Update(ctx, database); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if name := database.GetDatabaseName(); slices.Contains(systemSchemas, name) {
+		setReadyCondition(&database.Status.Conditions, database.Generation, metav1.ConditionFalse, "Rejected", fmt.Sprintf("schema %s belongs to the server", name))
+		return ctrl.Result{}, r.Client.Status().Update(ctx, database)
+	}
+	if reason, message := singleUnavailable(single, singleFound, database.Spec.SingleRef); reason != "" {
+		return r.waitForSingle(ctx, database, reason, message)
+	}
+	db, err := connectPrimary(ctx, r.Client, single)
+	if err != nil || db == nil {
+		return r.waitForSingle(ctx, database, "SingleUnavailable", fmt.Sprintf("single %s has no reachable primary", single.Name))
+	}
+	defer db.Close()
+
+	name := database.GetDatabaseName()
+	if err := db.EnsureDatabase(ctx, name, database.Spec.CharacterSet, database.Spec.Collation); err != nil {
+		log.Error(err, "Could not create schema", "database", name)
+		r.Recorder.Eventf(database, corev1.EventTypeWarning, "SyncFailed", "could not create the schema: %v", err)
+		setReadyCondition(&database.Status.Conditions, database.Generation, metav1.ConditionFalse, "SyncFailed", err.Error())
+		if statusErr := r.Client.Status().Update(ctx, database); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, err
+	}
+	database.Status.Name = name
+
+	users, err := r.syncUsers(ctx, database, single)
+	if err != nil {
+		reason := "SyncFailed"
+		if goerrors.Is(err, errNotOwned) {
+			reason = "NameConflict"
+		}
+		log.Error(err, "Could not sync the generated accounts")
+		r.Recorder.Eventf(database, corev1.EventTypeWarning, reason, "could not sync the generated accounts: %v", err)
+		setReadyCondition(&database.Status.Conditions, database.Generation, metav1.ConditionFalse, reason, err.Error())
+		if statusErr := r.Client.Status().Update(ctx, database); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, err
+	}
+
+	database.Status.Users = users
+	database.Status.ObservedGeneration = database.Generation
+	setReadyCondition(&database.Status.Conditions, database.Generation, metav1.ConditionTrue, "Synced", fmt.Sprintf("%s exists on %s", name, single.Name))
+	return ctrl.Result{RequeueAfter: accountSyncInterval}, r.Client.Status().Update(ctx, database)
+}
+
+// syncUsers creates the secret and GreatSQLUser of every enabled account and
+// deletes the ones of disabled accounts. It returns the names of the GreatSQLUsers.
+func (r *GreatSQLDatabaseReconciler) syncUsers(ctx context.Context, database *singlev1.GreatSQLDatabase, single *singlev1.Single) ([]string, error) {
+	name := database.GetDatabaseName()
+	roles := []struct {
+		role    string
+		enabled bool
+		grants  []singlev1.Grant
+	}{
+		{ownerRole, database.Spec.Users.Owner, []singlev1.Grant{{Privileges: []singlev1.Privilege{"ALL PRIVILEGES"}, Database: name, Table: "*"}}},
+		{readonlyRole, database.Spec.Users.Readonly, []singlev1.Grant{{Privileges: []singlev1.Privilege{"SELECT", "SHOW VIEW"}, Database: name, Table: "*"}}},
+	}
+
+	var users []string
+	for _, role := range roles {
+		desired := kube.NewDatabaseUser(database, role.role, databaseUsername(name, role.role), role.grants)
+		if !role.enabled {
+			if err := r.deleteOwned(ctx, database, &singlev1.GreatSQLUser{}, desired.Name); err != nil {
+				return nil, err
+			}
+			if err := r.deleteOwned(ctx, database, &corev1.Secret{}, kube.DatabaseSecretName(database, role.role)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		host, port := kube.ServiceAddress(single)
+		if role.role == readonlyRole {
+			host, port = kube.ReadServiceAddress(single)
+		}
+		if err := r.ensureSecret(ctx, database, role.role, desired.Spec.Username, host, port); err != nil {
+			return nil, err
+		}
+		if err := r.ensureUser(ctx, database, desired); err != nil {
+			return nil, err
+		}
+		users = append(users, desired.Name)
+	}
+	return users, nil
+}
+
+// ensureSecret creates the secret of a generated account with a random password,
+// an existing password is kept while the connection details follow the single
+func (r *GreatSQLDatabaseReconciler) ensureSecret(ctx context.Context, database *singlev1.GreatSQLDatabase, role, username, host string, port int32) error {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: database.Namespace, Name: kube.DatabaseSecretName(database, role)}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	found := err == nil
+	if found && !metav1.IsControlledBy(secret, database) {
+		return fmt.Errorf("secret %s %w", secret.Name, errNotOwned)
+	}
+
+	password := string(secret.Data[passwordKey])
+	if password == "" {
+		if password, err = utils.RandomPassword(24); err != nil {
+			return err
+		}
+	}
+	name := database.GetDatabaseName()
+	address := host + ":" + strconv.Itoa(int(port))
+	data := map[string][]byte{
+		usernameKey: []byte(username),
+		passwordKey: []byte(password),
+		"host":      []byte(host),
+		"port":      []byte(strconv.Itoa(int(port))),
+		"database":  []byte(name),
+		"dsn":       []byte(fmt.Sprintf("%s:%s@tcp(%s)/%s", username, password, address, name)),
+		"uri":       []byte(fmt.Sprintf("mysql://%s:%s@%s/%s", username, password, address, name)),
+	}
+
+	if !found {
+		return r.Client.Create(ctx, kube.NewDatabaseSecret(database, role, data))
+	}
+	if equality.Semantic.DeepEqual(secret.Data, data) {
+		return nil
+	}
+	secret.Data = data
+	return r.Client.Update(ctx, secret)
+}
+
+// ensureUser creates the GreatSQLUser of a generated account or brings its spec back
+func (r *GreatSQLDatabaseReconciler) ensureUser(ctx context.Context, database *singlev1.GreatSQLDatabase, desired *singlev1.GreatSQLUser) error {
+	user := &singlev1.GreatSQLUser{}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(desired), user)
+	if errors.IsNotFound(err) {
+		return r.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(user, database) {
+		return fmt.Errorf("greatsqluser %s %w", user.Name, errNotOwned)
+	}
+	if equality.Semantic.DeepEqual(user.Spec, desired.Spec) {
+		return nil
+	}
+	user.Spec = desired.Spec
+	return r.Client.Update(ctx, user)
+}
+
+// deleteOwned deletes the object of the given name if database owns it
+func (r *GreatSQLDatabaseReconciler) deleteOwned(ctx context.Context, database *singlev1.GreatSQLDatabase, obj client.Object, name string) error {
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: database.Namespace, Name: name}, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, database) {
+		return nil
+	}
+	return client.IgnoreNotFound(r.Client.Delete(ctx, obj))
+}
+
+// waitForSingle records why the schema can not be synced and retries later
+func (r *GreatSQLDatabaseReconciler) waitForSingle(ctx context.Context, database *singlev1.GreatSQLDatabase, reason, message string) (ctrl.Result, error) {
+	conditions := append([]metav1.Condition(nil), database.Status.Conditions...)
+	setReadyCondition(&database.Status.Conditions, database.Generation, metav1.ConditionFalse, reason, message)
+	if equality.Semantic.DeepEqual(conditions, database.Status.Conditions) {
+		return ctrl.Result{RequeueAfter: accountRetryInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: accountRetryInterval}, r.Client.Status().Update(ctx, database)
+}
+
+// databaseUsername returns the username of a generated account. A schema name
+// that has to be changed or cut to fit in 32 characters gets a hash of itself,
+// names that only differ in the changed part stay apart.
+func databaseUsername(database, role string) string {
+	username := strings.ReplaceAll(database, "-", "_")
+	if username != database || len(username)+1+len(role) > 32 {
+		hash := fnv.New32a()
+		hash.Write([]byte(database))
+		suffix := fmt.Sprintf("%08x", hash.Sum32())
+		if limit := 32 - len(role) - len(suffix) - 2; len(username) > limit {
+			username = username[:limit]
+		}
+		username += "_" + suffix
+	}
+	return username + "_" + role
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GreatSQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&singlev1.GreatSQLDatabase{}).
+		Owns(&singlev1.GreatSQLUser{}).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatabaseUsername(t *testing.T) {
+	long := strings.Repeat("a", 40)
+	cases := []struct {
+		database, role string
+		want           string
+	}{
+		{"app", ownerRole, "app_owner"},
+		{"app_db", readonlyRole, "app_db_readonly"},
+	}
+	for _, c := range cases {
+		if got := databaseUsername(c.database, c.role); got != c.want {
+			t.Errorf("databaseUsername(%q, %q) = %q, want %q", c.database, c.role, got, c.want)
+		}
+	}
+
+	// names that only differ in the changed or cut part must not share an account
+	distinct := [][2]string{
+		{"a-b", "a_b"},
+		{long + "x", long + "y"},
+	}
+	for _, pair := range distinct {
+		for _, role := range []string{ownerRole, readonlyRole} {
+			a, b := databaseUsername(pair[0], role), databaseUsername(pair[1], role)
+			if a == b {
+				t.Errorf("databaseUsername(%q) and databaseUsername(%q) are both %q", pair[0], pair[1], a)
+			}
+			if len(a) > 32 || len(b) > 32 {
+				t.Errorf("databaseUsername of %q or %q is longer than 32 characters: %q %q", pair[0], pair[1], a, b)
+			}
+		}
+	}
+}
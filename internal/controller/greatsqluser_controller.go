@@ -0,0 +1,389 @@
+package controller
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+	"github.com/keington/greatsql-operator/internal/utils"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-22 10:26:05
+ * @file: greatsqluser_controller.go
+ * @description: declarative database accounts
+ */
+
+const (
+	// accountSyncInterval is how often the accounts are compared with the spec
+	accountSyncInterval = 5 * time.Minute
+	// accountRetryInterval is how often an account waits for its single
+	accountRetryInterval = 30 * time.Second
+
+	// keys of the generated credentials secret
+	usernameKey = "username"
+	passwordKey = "password"
+)
+
+// GreatSQLUserReconciler reconciles a GreatSQLUser object
+type GreatSQLUserReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+var (
+	userLogger = ctrl.Log.WithName("greatsql-user-controller")
+
+	// globalPrivileges may be granted on *.*, they only let an account observe the server
+	globalPrivileges = []string{"PROCESS", "REPLICATION CLIENT", "SHOW DATABASES"}
+
+	// errAccountNotOwned is returned for an account created by hand or by another GreatSQLUser
+	errAccountNotOwned = goerrors.New("account exists and is not managed by this GreatSQLUser")
+)
+
+//+kubebuilder:rbac:groups=greatsql.greatsql.cn,resources=greatsqlusers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=greatsql.greatsql.cn,resources=greatsqlusers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=greatsql.greatsql.cn,resources=greatsqlusers/finalizers,verbs=update
+
+// Reconcile creates, alters and drops the accounts of a GreatSQLUser on the
+// primary of its single, the accounts replicate to the other members
+func (r *GreatSQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := userLogger.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+
+	user := &singlev1.GreatSQLUser{}
+	if err := r.Client.Get(ctx, req.NamespacedName, user); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	single := &singlev1.Single{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: user.Spec.SingleRef}, single)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	singleFound := err == nil && single.DeletionTimestamp == nil
+
+	// the accounts go with the resource, unless the single goes as well
+	if user.DeletionTimestamp != nil {
+		if singleFound && user.Status.Username != "" {
+			db, err := connectPrimary(ctx, r.Client, single)
+			if err != nil || db == nil {
+				log.Info("Waiting for the single to drop the accounts", "single", single.Name)
+				return ctrl.Result{RequeueAfter: accountRetryInterval}, nil
+			}
+			defer db.Close()
+			for _, host := range user.Status.Hosts {
+				if err := dropOwnedAccount(ctx, db, user, user.Status.Username, host); err != nil {
+					log.Error(err, "Could not drop account", "host", host)
+					return ctrl.Result{}, err
+				}
+			}
+			log.Info("Dropped accounts", "username", user.Status.Username)
+		}
+		controllerutil.RemoveFinalizer(user, user.Finalizer())
+		return ctrl.Result{}, r.Client.Update(ctx, user)
+	}
+	if controllerutil.AddFinalizer(user, user.Finalizer()) {
+		if err := r.Client.Update(ctx, user); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// nothing is created for a rejected spec, it is not retried until it changes
+	if err := validateAccount(user); err != nil {
+		if condition := meta.FindStatusCondition(user.Status.Conditions, singlev1.ConditionReady); condition == nil || condition.Reason != "Rejected" || condition.ObservedGeneration != user.Generation {
+			r.Recorder.Event(user, corev1.EventTypeWarning, "Rejected", err.Error())
+		}
+		setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionFalse, "Rejected", err.Error())
+		return ctrl.Result{}, r.Client.Status().Update(ctx, user)
+	}
+
+	if reason, message := singleUnavailable(single, singleFound, user.Spec.SingleRef); reason != "" {
+		return r.waitForSingle(ctx, user, reason, message)
+	}
+
+	password, version, err := r.password(ctx, user)
+	if err != nil {
+		return r.waitForSingle(ctx, user, "PasswordUnavailable", err.Error())
+	}
+	db, err := connectPrimary(ctx, r.Client, single)
+	if err != nil || db == nil {
+		return r.waitForSingle(ctx, user, "SingleUnavailable", fmt.Sprintf("single %s has no reachable primary", single.Name))
+	}
+	defer db.Close()
+
+	if err := r.syncAccounts(ctx, db, user, password, version); err != nil {
+		reason := "SyncFailed"
+		if goerrors.Is(err, errAccountNotOwned) {
+			reason = "AccountExists"
+		}
+		log.Error(err, "Could not sync accounts")
+		r.Recorder.Eventf(user, corev1.EventTypeWarning, reason, "could not sync the accounts: %v", err)
+		setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionFalse, reason, err.Error())
+		if statusErr := r.Client.Status().Update(ctx, user); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, err
+	}
+
+	setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionTrue, "Synced", fmt.Sprintf("%s exists on %s", user.GetUsername(), single.Name))
+	return ctrl.Result{RequeueAfter: accountSyncInterval}, r.Client.Status().Update(ctx, user)
+}
+
+// syncAccounts brings the accounts of user to its spec. The password, plugin and
+// limits are only set again when the spec or the password changed, the grants are
+// given on every sync so privileges revoked by hand come back.
+func (r *GreatSQLUserReconciler) syncAccounts(ctx context.Context, db *greatsql.Client, user *singlev1.GreatSQLUser, password, version string) error {
+	username, hosts := user.GetUsername(), user.GetHosts()
+	changed := user.Status.ObservedGeneration != user.Generation || user.Status.PasswordVersion != version
+
+	// an account this resource did not create is left alone, it may be one the
+	// applications or another GreatSQLUser depend on
+	owned := make([]bool, len(hosts))
+	for i, host := range hosts {
+		owner, exists, err := db.AccountOwner(ctx, username, host)
+		if err != nil {
+			return err
+		}
+		if exists && !ownsAccount(user, username, host, owner) {
+			return fmt.Errorf("%s@%s: %w", username, host, errAccountNotOwned)
+		}
+		owned[i] = exists && owner == accountOwner(user)
+	}
+
+	// accounts of a previous username or host
+	for _, host := range user.Status.Hosts {
+		if user.Status.Username != username || !slices.Contains(hosts, host) {
+			if err := dropOwnedAccount(ctx, db, user, user.Status.Username, host); err != nil {
+				return err
+			}
+		}
+	}
+
+	var revoked []singlev1.Grant
+	for _, grant := range user.Status.Grants {
+		if !slices.ContainsFunc(user.Spec.Grants, func(g singlev1.Grant) bool { return equality.Semantic.DeepEqual(g, grant) }) {
+			revoked = append(revoked, grant)
+		}
+	}
+
+	options := greatsql.AccountOptions{
+		Password:              password,
+		Plugin:                user.Spec.AuthenticationPlugin,
+		MaxQueriesPerHour:     user.Spec.Resources.MaxQueriesPerHour,
+		MaxUpdatesPerHour:     user.Spec.Resources.MaxUpdatesPerHour,
+		MaxConnectionsPerHour: user.Spec.Resources.MaxConnectionsPerHour,
+		MaxUserConnections:    user.Spec.Resources.MaxUserConnections,
+		Owner:                 accountOwner(user),
+	}
+	for i, host := range hosts {
+		// accounts created before the owner was recorded get it with the next change
+		if !owned[i] || changed {
+			if err := db.EnsureAccount(ctx, username, host, options); err != nil {
+				return err
+			}
+		}
+		for _, grant := range revoked {
+			if err := db.RevokePrivileges(ctx, username, host, toGrant(grant)); err != nil {
+				return err
+			}
+		}
+		for _, grant := range user.Spec.Grants {
+			if err := db.GrantPrivileges(ctx, username, host, toGrant(grant)); err != nil {
+				return err
+			}
+		}
+	}
+
+	user.Status.Username = username
+	user.Status.Hosts = hosts
+	user.Status.Grants = user.Spec.Grants
+	user.Status.PasswordVersion = version
+	user.Status.ObservedGeneration = user.Generation
+	return nil
+}
+
+// validateAccount rejects the accounts the operator and the server depend on and
+// grants that would hand out control of the server
+func validateAccount(user *singlev1.GreatSQLUser) error {
+	if username := user.GetUsername(); reservedUsername(username) {
+		return fmt.Errorf("username %s is reserved", username)
+	}
+	for i, grant := range user.Spec.Grants {
+		if grant.Database == "mysql" {
+			return fmt.Errorf("grants[%d]: privileges on the mysql schema can not be granted", i)
+		}
+		if grant.Database != "*" {
+			continue
+		}
+		if grant.WithGrantOption {
+			return fmt.Errorf("grants[%d]: global privileges can not be granted with grant option", i)
+		}
+		for _, privilege := range grant.Privileges {
+			if !slices.Contains(globalPrivileges, strings.ToUpper(string(privilege))) {
+				return fmt.Errorf("grants[%d]: %s can not be granted globally, only %s", i, privilege, strings.Join(globalPrivileges, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// reservedUsername returns true for the accounts of the server and of the operator
+func reservedUsername(username string) bool {
+	return username == greatsql.RootUser || username == replicationUser ||
+		strings.HasPrefix(username, cloneUserPrefix) || strings.HasPrefix(username, "mysql.")
+}
+
+// accountOwner returns the owner recorded on the accounts of user
+func accountOwner(user *singlev1.GreatSQLUser) string {
+	return "greatsqluser/" + user.Namespace + "/" + user.Name
+}
+
+// ownsAccount returns true if user created the existing account username@host.
+// Accounts created before the owner was recorded are recognized by the status.
+func ownsAccount(user *singlev1.GreatSQLUser, username, host, owner string) bool {
+	if owner != "" {
+		return owner == accountOwner(user)
+	}
+	return user.Status.Username == username && slices.Contains(user.Status.Hosts, host)
+}
+
+// dropOwnedAccount drops username@host if user created it
+func dropOwnedAccount(ctx context.Context, db *greatsql.Client, user *singlev1.GreatSQLUser, username, host string) error {
+	owner, exists, err := db.AccountOwner(ctx, username, host)
+	if err != nil || !exists || !ownsAccount(user, username, host, owner) {
+		return err
+	}
+	return db.DropAccount(ctx, username, host)
+}
+
+// password returns the password of the account and the resourceVersion of its
+// secret. Without a password secret one is generated into the credentials secret.
+func (r *GreatSQLUserReconciler) password(ctx context.Context, user *singlev1.GreatSQLUser) (string, string, error) {
+	if ref := user.Spec.PasswordSecret; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: ref.Name}, secret); err != nil {
+			return "", "", err
+		}
+		password, ok := secret.Data[ref.Key]
+		if !ok {
+			return "", "", fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+		}
+		return string(password), secret.ResourceVersion, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: kube.CredentialsSecretName(user)}, secret)
+	if errors.IsNotFound(err) {
+		password, err := utils.RandomPassword(24)
+		if err != nil {
+			return "", "", err
+		}
+		secret = kube.NewCredentialsSecret(user, map[string][]byte{
+			usernameKey: []byte(user.GetUsername()),
+			passwordKey: []byte(password),
+		})
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return "", "", err
+		}
+		return password, secret.ResourceVersion, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return string(secret.Data[passwordKey]), secret.ResourceVersion, nil
+}
+
+// waitForSingle records why the accounts can not be synced and retries later
+func (r *GreatSQLUserReconciler) waitForSingle(ctx context.Context, user *singlev1.GreatSQLUser, reason, message string) (ctrl.Result, error) {
+	conditions := append([]metav1.Condition(nil), user.Status.Conditions...)
+	setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionFalse, reason, message)
+	if equality.Semantic.DeepEqual(conditions, user.Status.Conditions) {
+		return ctrl.Result{RequeueAfter: accountRetryInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: accountRetryInterval}, r.Client.Status().Update(ctx, user)
+}
+
+// toGrant converts a grant of the spec
+func toGrant(grant singlev1.Grant) greatsql.Grant {
+	privileges := make([]string, 0, len(grant.Privileges))
+	for _, privilege := range grant.Privileges {
+		privileges = append(privileges, string(privilege))
+	}
+	return greatsql.Grant{Privileges: privileges, Database: grant.Database, Table: grant.Table, WithGrantOption: grant.WithGrantOption}
+}
+
+// singleUnavailable returns why nothing can be created on the referenced single
+// yet, an empty reason once it accepts writes
+func singleUnavailable(single *singlev1.Single, found bool, ref string) (string, string) {
+	switch {
+	case !found:
+		return "SingleNotFound", fmt.Sprintf("single %s does not exist", ref)
+	case single.Spec.IsStandby() && !standbyPromoted(single):
+		return "Standby", "the objects of a standby replicate from its primary instance"
+	case single.Spec.Paused || single.Spec.Stopped:
+		return "SingleUnavailable", fmt.Sprintf("single %s is paused or stopped", single.Name)
+	}
+	return "", ""
+}
+
+// setReadyCondition sets the Ready condition of a GreatSQLUser or GreatSQLDatabase
+func setReadyCondition(conditions *[]metav1.Condition, generation int64, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               singlev1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// connectPrimary connects as root to the primary of single, nil while no pod is
+// ready. The helpers of the single controller only need the client.
+func connectPrimary(ctx context.Context, c client.Client, single *singlev1.Single) (*greatsql.Client, error) {
+	singles := &SingleReconciler{Client: c}
+	pod, err := singles.primaryPod(ctx, single)
+	if err != nil || pod == nil {
+		return nil, err
+	}
+	password, err := singles.rootPassword(ctx, single)
+	if err != nil {
+		return nil, err
+	}
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GreatSQLUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&singlev1.GreatSQLUser{}).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
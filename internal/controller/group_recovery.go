@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 17:12:30
+ * @file: group_recovery.go
+ * @description: MGR full outage recovery
+ */
+
+// recoverGroup bootstraps a group whose members are all stopped from the most
+// advanced member. When some members are unreachable or the members diverged the
+// choice could lose transactions, it is only made with the force annotation.
+// It returns the member the group was bootstrapped from, nil while waiting.
+func (r *SingleReconciler) recoverGroup(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, account replicationAccount) (*member, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	sets := map[string]greatsql.GTIDSet{}
+	var unreachable []string
+	for _, m := range members {
+		if !m.reachable() {
+			unreachable = append(unreachable, m.pod.Name)
+			continue
+		}
+
+		// nothing may be written while the members are compared
+		if err := m.db.SetReadOnly(ctx, true); err != nil {
+			return nil, err
+		}
+		gtid, err := m.db.GTIDExecuted(ctx)
+		if err != nil {
+			return nil, err
+		}
+		received, err := m.db.ReceivedGTIDSet(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.gtid = gtid
+		sets[m.pod.Name] = gtid.Union(received)
+	}
+	if len(members) < int(singleGreatsql.Spec.GetSize()) {
+		unreachable = append(unreachable, fmt.Sprintf("%d missing pods", int(singleGreatsql.Spec.GetSize())-len(members)))
+	}
+	if len(sets) == 0 {
+		setCondition(singleGreatsql, singlev1.ConditionGroupOnline, metav1.ConditionFalse, "GroupOffline", "no member of the group is reachable")
+		return nil, nil
+	}
+
+	choice := greatsql.MostAdvanced(sets)
+	var risk string
+	switch {
+	case len(unreachable) > 0:
+		risk = fmt.Sprintf("members %v are unreachable and may hold newer transactions", unreachable)
+	case !isSuperset(sets, choice):
+		risk = "members diverged, no member holds every transaction"
+	}
+
+	force := singleGreatsql.Annotations[consts.ForceBootstrap]
+	if force != "" && force != "true" {
+		choice = force
+	}
+	seed := findMember(members, choice)
+
+	if risk != "" && force == "" {
+		message := fmt.Sprintf("%s, annotate %s=<pod> to bootstrap anyway (suggested %s)", risk, consts.ForceBootstrap, choice)
+		log.Info("Group bootstrap needs confirmation", "reason", message)
+		setCondition(singleGreatsql, singlev1.ConditionGroupOnline, metav1.ConditionFalse, "WaitingForForce", message)
+		return nil, nil
+	}
+	if !seed.reachable() {
+		setCondition(singleGreatsql, singlev1.ConditionGroupOnline, metav1.ConditionFalse, "InvalidForce", choice+" is not a reachable member")
+		return nil, nil
+	}
+
+	log.Info("Bootstrapping group after full outage", "member", seed.pod.Name, "gtid", sets[seed.pod.Name].String(), "forced", force != "")
+	if err := r.startGroupMember(ctx, singleGreatsql, seed, account, true); err != nil {
+		return nil, err
+	}
+
+	if force != "" {
+		delete(singleGreatsql.Annotations, consts.ForceBootstrap)
+		if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+			return nil, err
+		}
+	}
+	setCondition(singleGreatsql, singlev1.ConditionGroupOnline, metav1.ConditionTrue, "Recovered", "group bootstrapped from "+seed.pod.Name+" after full outage")
+	return seed, nil
+}
+
+// isSuperset returns true if the set of key contains every other set
+func isSuperset(sets map[string]greatsql.GTIDSet, key string) bool {
+	for _, set := range sets {
+		if !sets[key].Contains(set) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 15:40:12
+ * @file: group_replication.go
+ * @description: MGR topologies, member rejoin and primary election
+ */
+
+const (
+	groupRolePrimary   = "PRIMARY"
+	groupRoleSecondary = "SECONDARY"
+)
+
+// groupView is the group membership reported by an ONLINE member, keyed by pod name
+type groupView map[string]greatsql.GroupMember
+
+// reconcileGroupReplication bootstraps the group, reflects the member states,
+// rejoins members that left and follows the primary elected by the group
+func (r *SingleReconciler) reconcileGroupReplication(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	rootPassword, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	account, err := r.replicationCredentials(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+
+	members, err := r.observeMembers(ctx, singleGreatsql, rootPassword)
+	if err != nil {
+		return err
+	}
+	defer closeMembers(members)
+
+	view := observeGroup(ctx, members)
+	if view == nil {
+		if meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionGroupOnline) != nil {
+			// every member stopped, the group can not start by itself
+			seed, err := r.recoverGroup(ctx, singleGreatsql, members, account)
+			if err != nil || seed == nil {
+				if updateErr := r.updateClusterStatus(ctx, singleGreatsql, members, nil); updateErr != nil {
+					return updateErr
+				}
+				return err
+			}
+		} else {
+			// first start, create the group from the first ordinal
+			seed := bootstrapCandidate(members)
+			if seed == nil {
+				log.Info("Waiting for members to start before bootstrapping the group")
+				return r.updateClusterStatus(ctx, singleGreatsql, members, nil)
+			}
+			// a standby group starts from a copy of its primary instance
+			if seeded, err := r.seedStandby(ctx, singleGreatsql, seed, rootPassword); err != nil || !seeded {
+				if updateErr := r.updateClusterStatus(ctx, singleGreatsql, members, nil); updateErr != nil {
+					return updateErr
+				}
+				return err
+			}
+			if err := r.startGroupMember(ctx, singleGreatsql, seed, account, true); err != nil {
+				return err
+			}
+			if err := seed.db.EnsureReplicationUser(ctx, account.user, account.password, kube.MutualTLS(singleGreatsql)); err != nil {
+				return err
+			}
+			log.Info("Bootstrapped group", "member", seed.pod.Name)
+			setCondition(singleGreatsql, singlev1.ConditionGroupOnline, metav1.ConditionTrue, "Bootstrapped", "group bootstrapped from "+seed.pod.Name)
+		}
+
+		if view = observeGroup(ctx, members); view == nil {
+			return r.updateClusterStatus(ctx, singleGreatsql, members, nil)
+		}
+	}
+
+	if target := singleGreatsql.Annotations[consts.SwitchoverTarget]; target != "" {
+		r.setGroupPrimary(ctx, singleGreatsql, members, view, target)
+	}
+	setCondition(singleGreatsql, singlev1.ConditionGroupOnline, metav1.ConditionTrue, "Online", fmt.Sprintf("%d members in the group", len(view)))
+
+	if err := r.scaleInGroup(ctx, singleGreatsql, members, view); err != nil {
+		log.Error(err, "Could not scale in")
+	}
+
+	// members that restarted come back OFFLINE, members expelled by the group end up in ERROR
+	for _, m := range members {
+		if !m.reachable() || departing(singleGreatsql, m) || (m.state != singlev1.MemberStateOffline && m.state != singlev1.MemberStateError) {
+			continue
+		}
+		log.Info("Rejoining member", "member", m.pod.Name, "state", m.state)
+		if err := r.startGroupMember(ctx, singleGreatsql, m, account, false); err != nil {
+			log.Error(err, "Could not rejoin member", "member", m.pod.Name)
+			m.state = singlev1.MemberStateError
+			continue
+		}
+		m.state = singlev1.MemberStateRecovering
+	}
+
+	var primary *member
+	for _, m := range members {
+		m.role = ""
+		if m.state != singlev1.MemberStateOnline {
+			continue
+		}
+		switch view[m.pod.Name].Role {
+		case groupRolePrimary:
+			m.role = singlev1.PrimaryRole
+			if singleGreatsql.Spec.GreatSqlType == singlev1.GreatSqlTypeSinglePrimaryGroupCluster {
+				primary = m
+			}
+		case groupRoleSecondary:
+			m.role = singlev1.SencondaryRole
+		}
+	}
+	// the recovery account follows tls.mutualTLS, nothing is written while it does
+	for _, m := range members {
+		if m.role == singlev1.PrimaryRole {
+			if err := m.db.EnsureReplicationUser(ctx, account.user, account.password, kube.MutualTLS(singleGreatsql)); err != nil {
+				log.Error(err, "Could not update the recovery account", "member", m.pod.Name)
+			}
+			break
+		}
+	}
+	if primary != nil {
+		if err := r.reconcileStandby(ctx, singleGreatsql, members, primary); err != nil {
+			log.Error(err, "Could not replicate from the primary instance")
+		}
+	}
+
+	// members on an outdated template are restarted one at a time
+	healthy := func(m *member) bool {
+		return podReady(m.pod) && m.state == singlev1.MemberStateOnline
+	}
+	switchover := func(candidate *member) error {
+		if err := candidate.db.SetAsPrimary(ctx, view[candidate.pod.Name].ID); err != nil {
+			return err
+		}
+		primary.role, candidate.role = singlev1.SencondaryRole, singlev1.PrimaryRole
+		primary = candidate
+		return nil
+	}
+	if err := r.rollMembers(ctx, singleGreatsql, members, primary, healthy, switchover); err != nil {
+		log.Error(err, "Could not roll out members")
+	}
+
+	if err := r.labelMembers(ctx, members); err != nil {
+		return err
+	}
+
+	return r.updateClusterStatus(ctx, singleGreatsql, members, primary)
+}
+
+// observeGroup reads the group state of every reachable member. It returns the
+// membership reported by an ONLINE member keyed by pod name, nil if none is ONLINE.
+func observeGroup(ctx context.Context, members []*member) groupView {
+	var view groupView
+	for _, m := range members {
+		if !m.reachable() {
+			continue
+		}
+
+		uuid, err := m.db.ServerUUID(ctx)
+		if err != nil {
+			continue
+		}
+		groupMembers, err := m.db.GroupMembers(ctx)
+		if err != nil {
+			continue
+		}
+
+		m.state = singlev1.MemberStateOffline
+		for _, gm := range groupMembers {
+			if gm.ID == uuid {
+				m.state = gm.State
+			}
+		}
+		if m.state != singlev1.MemberStateOnline || view != nil {
+			continue
+		}
+
+		view = groupView{}
+		for _, gm := range groupMembers {
+			// members report their fqdn through report_host
+			view[strings.SplitN(gm.Host, ".", 2)[0]] = gm
+		}
+	}
+	return view
+}
+
+// startGroupMember configures the member and starts group replication on it
+func (r *SingleReconciler) startGroupMember(ctx context.Context, singleGreatsql *singlev1.Single, m *member, account replicationAccount, bootstrap bool) error {
+	if m.state == singlev1.MemberStateError {
+		if err := m.db.StopGroupReplication(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := m.db.ConfigureGroupReplication(ctx, groupConfig(singleGreatsql, m, account)); err != nil {
+		return err
+	}
+	if err := m.db.StartGroupReplication(ctx, bootstrap); err != nil {
+		return err
+	}
+	m.state = singlev1.MemberStateOnline
+	return nil
+}
+
+// setGroupPrimary asks the group to elect target as the new primary
+func (r *SingleReconciler) setGroupPrimary(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, view groupView, target string) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	delete(singleGreatsql.Annotations, consts.SwitchoverTarget)
+	if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not clear switchover annotation")
+		return
+	}
+
+	candidate, inGroup := view[target]
+	var err error
+	switch {
+	case singleGreatsql.Spec.GreatSqlType != singlev1.GreatSqlTypeSinglePrimaryGroupCluster:
+		err = fmt.Errorf("every member is a primary in %s mode", singleGreatsql.Spec.GreatSqlType)
+	case !inGroup || candidate.State != singlev1.MemberStateOnline:
+		err = fmt.Errorf("%s is not an ONLINE member of the group", target)
+	case candidate.Role == groupRolePrimary:
+		err = fmt.Errorf("%s is already the primary", target)
+	}
+	if err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "InvalidTarget", err.Error())
+		return
+	}
+
+	for _, m := range members {
+		if m.reachable() && m.state == singlev1.MemberStateOnline {
+			if err = m.db.SetAsPrimary(ctx, candidate.ID); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "SwitchoverFailed", err.Error())
+		return
+	}
+
+	candidate.Role = groupRolePrimary
+	for name, gm := range view {
+		if name != target && gm.Role == groupRolePrimary {
+			gm.Role = groupRoleSecondary
+			view[name] = gm
+		}
+	}
+	view[target] = candidate
+
+	log.Info("Elected new group primary", "member", target)
+	setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionTrue, "PrimaryElected", "group elected "+target+" as primary")
+}
+
+// groupConfig returns the group settings of a member
+func groupConfig(singleGreatsql *singlev1.Single, m *member, account replicationAccount) greatsql.GroupConfig {
+	seeds := make([]string, 0, singleGreatsql.Spec.GetSize())
+	for i := int32(0); i < singleGreatsql.Spec.GetSize(); i++ {
+		podName := fmt.Sprintf("%s-%d", singleGreatsql.Name, i)
+		seeds = append(seeds, groupAddress(singleGreatsql, podName))
+	}
+
+	return greatsql.GroupConfig{
+		// the uid is a uuid unique to this single, reused as the group name
+		GroupName:        string(singleGreatsql.UID),
+		LocalAddress:     groupAddress(singleGreatsql, m.pod.Name),
+		Seeds:            seeds,
+		SinglePrimary:    singleGreatsql.Spec.GreatSqlType == singlev1.GreatSqlTypeSinglePrimaryGroupCluster,
+		RecoveryUser:     account.user,
+		RecoveryPassword: account.password,
+		TLS:              memberTLS(singleGreatsql, m.pod.Name),
+	}
+}
+
+// groupAddress returns the group communication address of a member
+func groupAddress(singleGreatsql *singlev1.Single, podName string) string {
+	return fmt.Sprintf("%s:%d", kube.PodFQDN(singleGreatsql, podName), greatsql.GroupReplicationPort)
+}
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 18:40:02
+ * @file: import.go
+ * @description: import a server running outside the operator
+ */
+
+const (
+	// cutoverApplyTimeout bounds how long the cutover waits for the relay logs to be applied
+	cutoverApplyTimeout = time.Minute
+)
+
+// reconcileImport seeds the single from dataSource.external, then keeps it
+// replicating from the external server until the cutover annotation promotes it.
+// It returns true while the seed is in progress.
+func (r *SingleReconciler) reconcileImport(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	dataSource := singleGreatsql.Spec.DataSource
+	if dataSource == nil || dataSource.External == nil {
+		return false, nil
+	}
+
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionImport)
+	if condition != nil && condition.Reason == "CutOver" {
+		return false, nil
+	}
+	if condition != nil && condition.Reason == "Replicating" {
+		if singleGreatsql.Annotations[consts.Cutover] == "" {
+			return false, nil
+		}
+		return false, r.cutover(ctx, singleGreatsql)
+	}
+
+	key := singleGreatsql.Namespace + "/" + singleGreatsql.Name
+	if _, running := cloning.Load(key); running {
+		return true, nil
+	}
+	if err, failed := cloneFailures.LoadAndDelete(key); failed {
+		setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionFalse, "SeedFailed", err.(error).Error())
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "SeedFailed", "seeding from %s failed, retrying: %v", dataSource.External.Host, err)
+	}
+
+	pod, err := r.runningPod(ctx, singleGreatsql)
+	if err != nil || pod == nil {
+		return true, err
+	}
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	source, err := r.externalSource(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+
+	db, err := r.connectCloned(ctx, singleGreatsql, singlev1.ConditionImport, pod.Status.PodIP, password, source)
+	if err != nil || db == nil {
+		return true, err
+	}
+	defer db.Close()
+
+	seeded := false
+	switch dataSource.External.SeedMethod {
+	case singlev1.SeedMethodDump:
+		seeded, err = r.seedWithDump(ctx, singleGreatsql)
+	default:
+		seeded, err = r.seedWithClone(ctx, singleGreatsql, db, pod.Status.PodIP, password, source)
+	}
+	if err != nil || !seeded {
+		if statusErr := r.Client.Status().Update(ctx, singleGreatsql); statusErr != nil {
+			return true, statusErr
+		}
+		return true, err
+	}
+
+	// the configMap gives every single the same server_id, a replica needs its own
+	if err := db.SetServerID(ctx, serverIDFor(singleGreatsql)); err != nil {
+		return true, err
+	}
+	if err := db.SetReadOnly(ctx, true); err != nil {
+		return true, err
+	}
+	if err := db.ChangeReplicationSource(ctx, source); err != nil {
+		return true, err
+	}
+	if err := db.StartReplica(ctx); err != nil {
+		return true, err
+	}
+
+	message := fmt.Sprintf("replicating from %s:%d", source.Host, source.Port)
+	logger.Info("Imported external server", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "source", source.Host)
+	setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionTrue, "Replicating", message)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Imported", message)
+	return false, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// connectCloned connects as root. The clone copies the accounts of the source,
+// if root no longer takes MYSQL_ROOT_PASSWORD the account of the credentialsSecret
+// sets it back, a failure is reported on conditionType. It returns nil while the
+// server restarts.
+func (r *SingleReconciler) connectCloned(ctx context.Context, singleGreatsql *singlev1.Single, conditionType, host, password string, source greatsql.ReplicationSource) (*greatsql.Client, error) {
+	db, err := connectWithAny(ctx, host, password)
+	if err == nil {
+		return db, nil
+	}
+	if !greatsql.IsAccessDenied(err) {
+		// the server restarts after a clone
+		return nil, nil
+	}
+
+	account, err := greatsql.NewClient(host, greatsql.DefaultPort, source.User, source.Password)
+	if err != nil {
+		return nil, err
+	}
+	defer account.Close()
+	if err := account.SetRootPassword(ctx, password); err != nil {
+		message := fmt.Sprintf("root does not take MYSQL_ROOT_PASSWORD after the clone and %s cannot reset it, grant it CREATE USER or use the root password of the source: %v", source.User, err)
+		setCondition(singleGreatsql, conditionType, metav1.ConditionFalse, "RootPasswordMismatch", message)
+		return nil, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	logger.Info("Reset root password after clone", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "RootPasswordReset", "root password reset to MYSQL_ROOT_PASSWORD after the clone")
+
+	return connectWithAny(ctx, host, password)
+}
+
+// seedWithClone clones the external server in the background, the clone is done
+// once the single restarted with the gtid set of the source
+func (r *SingleReconciler) seedWithClone(ctx context.Context, singleGreatsql *singlev1.Single, db *greatsql.Client, host, password string, source greatsql.ReplicationSource) (bool, error) {
+	gtid, err := db.GTIDExecuted(ctx)
+	if err != nil || gtid.Count() > 0 {
+		return err == nil, err
+	}
+
+	key := singleGreatsql.Namespace + "/" + singleGreatsql.Name
+	cloning.Store(key, struct{}{})
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "source", source.Host)
+	go func() {
+		defer cloning.Delete(key)
+
+		recipient, err := greatsql.NewClient(host, greatsql.DefaultPort, greatsql.RootUser, password)
+		if err == nil {
+			defer recipient.Close()
+			log.Info("Cloning external server into single")
+			err = recipient.Clone(context.Background(), source)
+		}
+		if err != nil {
+			log.Error(err, "Could not clone external server into single")
+			cloneFailures.Store(key, err)
+			return
+		}
+		log.Info("Clone finished, single restarts with the source data")
+	}()
+
+	setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionFalse, "Seeding", fmt.Sprintf("cloning %s:%d", source.Host, source.Port))
+	return false, nil
+}
+
+// seedWithDump runs the import job, the dump is done once the job succeeded
+func (r *SingleReconciler) seedWithDump(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	external := singleGreatsql.Spec.DataSource.External
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.ImportJobName(singleGreatsql)}, job)
+	if errors.IsNotFound(err) {
+		rootPassword, found := rootPasswordEnvVar(singleGreatsql)
+		if !found {
+			return false, fmt.Errorf("env %s is required", rootPasswordEnv)
+		}
+		setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionFalse, "Seeding", fmt.Sprintf("dumping %s:%d", external.Host, external.Port))
+		return false, r.Client.Create(ctx, kube.NewImportJob(singleGreatsql, rootPassword))
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		return true, r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	case job.Status.Failed > 0:
+		// the gtid set of the dump is already applied, a retry needs an empty single
+		setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionFalse, "SeedFailed",
+			fmt.Sprintf("job %s failed, recreate the single with an empty data volume to retry", job.Name))
+	}
+	return false, nil
+}
+
+// cutover promotes the single once it applied everything it received from the
+// external server. Writes on the external server have to be stopped before.
+func (r *SingleReconciler) cutover(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	delete(singleGreatsql.Annotations, consts.Cutover)
+	if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+		return err
+	}
+
+	err := r.detachReplica(ctx, singleGreatsql)
+	if err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionTrue, "Replicating", "cutover failed: "+err.Error())
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "CutoverFailed", "cutover failed: %v", err)
+	} else {
+		setCondition(singleGreatsql, singlev1.ConditionImport, metav1.ConditionTrue, "CutOver", "promoted, replication from "+singleGreatsql.Spec.DataSource.External.Host+" stopped")
+		r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "CutOver", "promoted, replication from the external server stopped")
+	}
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// detachReplica waits for the relay logs to be applied, stops replication and
+// makes the single writable
+func (r *SingleReconciler) detachReplica(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	pod, err := r.readyPod(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	if pod == nil {
+		return fmt.Errorf("no ready pod")
+	}
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	status, err := db.ReplicaStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status != nil {
+		retrieved, err := greatsql.ParseGTIDSet(status.RetrievedGTIDSet)
+		if err != nil {
+			return err
+		}
+		applied, err := db.WaitForExecutedGTIDSet(ctx, retrieved, cutoverApplyTimeout)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			return fmt.Errorf("relay logs not applied within %s", cutoverApplyTimeout)
+		}
+		if err := db.StopReplica(ctx); err != nil {
+			return err
+		}
+		if err := db.ResetReplicaAll(ctx); err != nil {
+			return err
+		}
+	}
+	return db.SetReadOnly(ctx, false)
+}
+
+// externalSource returns the external server and the account to replicate with
+func (r *SingleReconciler) externalSource(ctx context.Context, singleGreatsql *singlev1.Single) (greatsql.ReplicationSource, error) {
+	external := singleGreatsql.Spec.DataSource.External
+	return r.sourceWithCredentials(ctx, singleGreatsql, external.Host, external.Port, external.CredentialsSecret)
+}
+
+// sourceWithCredentials returns a server outside the operator, the account comes
+// from the user and password keys of a secret in the namespace of the single
+func (r *SingleReconciler) sourceWithCredentials(ctx context.Context, singleGreatsql *singlev1.Single, host string, port int32, secretName string) (greatsql.ReplicationSource, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: secretName}, secret); err != nil {
+		return greatsql.ReplicationSource{}, err
+	}
+
+	if port == 0 {
+		port = greatsql.DefaultPort
+	}
+	return greatsql.ReplicationSource{
+		Host:     host,
+		Port:     port,
+		User:     string(secret.Data["user"]),
+		Password: string(secret.Data["password"]),
+	}, nil
+}
+
+// rootPasswordEnvVar returns the MYSQL_ROOT_PASSWORD env of the container
+func rootPasswordEnvVar(singleGreatsql *singlev1.Single) (corev1.EnvVar, bool) {
+	for _, env := range singleGreatsql.Spec.PodSpec.Envs {
+		if env.Name == rootPasswordEnv {
+			return env, true
+		}
+	}
+	return corev1.EnvVar{}, false
+}
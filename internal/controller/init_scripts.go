@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-23 10:05:38
+ * @file: init_scripts.go
+ * @description: run the init scripts of a new instance
+ */
+
+// initScript is a .sql key of a configMap or secret of spec.initScripts
+type initScript struct {
+	name string // configmap/<name>/<key> or secret/<name>/<key>
+	sql  string
+}
+
+// reconcileInitScripts runs spec.initScripts once on the primary. A script that
+// succeeded is never run again, a failed script runs again once the scripts
+// change. It returns true until every script succeeded.
+func (r *SingleReconciler) reconcileInitScripts(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	// scripts added to an existing instance would run against its live data
+	if len(singleGreatsql.Spec.InitScripts) > 0 && singleGreatsql.Status.InitScripts == nil {
+		condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionInitScripts)
+		if condition != nil && condition.Reason == "Rejected" {
+			return false, nil
+		}
+		message := "initScripts only run on the datadir of a new instance, they were added to an existing one"
+		setCondition(singleGreatsql, singlev1.ConditionInitScripts, metav1.ConditionFalse, "Rejected", message)
+		r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, "InitScriptsRejected", message)
+		return false, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	if !initScriptsPending(singleGreatsql) {
+		return false, nil
+	}
+
+	scripts, err := r.loadInitScripts(ctx, singleGreatsql)
+	if errors.IsNotFound(err) {
+		setCondition(singleGreatsql, singlev1.ConditionInitScripts, metav1.ConditionFalse, "ScriptsNotFound", err.Error())
+		return true, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	if err != nil {
+		return true, err
+	}
+	checksum := initScriptsChecksum(scripts)
+
+	status := singleGreatsql.Status.InitScripts
+	if status == nil {
+		status = &singlev1.InitScriptsStatus{}
+	}
+	// the failed script is not retried as is, it would most likely fail again
+	if status.Failed != "" && status.Checksum == checksum {
+		return true, nil
+	}
+
+	// a cluster runs the scripts once its primary is elected, they replicate to the members
+	if singleGreatsql.Spec.IsCluster() && singleGreatsql.Status.Primary == "" {
+		return true, nil
+	}
+	pod, err := r.primaryPod(ctx, singleGreatsql)
+	if err != nil || pod == nil {
+		return true, err
+	}
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return true, err
+	}
+	defer db.Close()
+	if err := db.Ping(ctx); err != nil {
+		// the datadir is still being initialized
+		return true, nil
+	}
+
+	status.Checksum = checksum
+	status.Failed = ""
+	singleGreatsql.Status.InitScripts = status
+	for _, script := range scripts {
+		if slices.Contains(status.Executed, script.name) {
+			continue
+		}
+		if err := db.ExecScript(ctx, script.name, script.sql); err != nil {
+			logger.Error(err, "Init script failed", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "script", script.name)
+			status.Failed = script.name
+			setCondition(singleGreatsql, singlev1.ConditionInitScripts, metav1.ConditionFalse, "Failed", err.Error())
+			r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "InitScriptFailed", "%v, it runs again once the script is changed", err)
+			return true, r.Client.Status().Update(ctx, singleGreatsql)
+		}
+		// recorded right away, a script must not run twice if a later one fails
+		status.Executed = append(status.Executed, script.name)
+		setCondition(singleGreatsql, singlev1.ConditionInitScripts, metav1.ConditionFalse, "Running", fmt.Sprintf("executed %s", script.name))
+		if err := r.Client.Status().Update(ctx, singleGreatsql); err != nil {
+			return true, err
+		}
+	}
+
+	message := fmt.Sprintf("executed %d scripts", len(status.Executed))
+	logger.Info("Init scripts executed", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "scripts", len(status.Executed))
+	setCondition(singleGreatsql, singlev1.ConditionInitScripts, metav1.ConditionTrue, "Executed", message)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "InitScriptsExecuted", message)
+	return false, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// loadInitScripts returns the .sql keys of spec.initScripts, the keys of every
+// configMap or secret in lexical order
+func (r *SingleReconciler) loadInitScripts(ctx context.Context, singleGreatsql *singlev1.Single) ([]initScript, error) {
+	var scripts []initScript
+	for _, source := range singleGreatsql.Spec.InitScripts {
+		data := map[string]string{}
+		var prefix string
+		switch {
+		case source.ConfigMap != nil:
+			configMap := &corev1.ConfigMap{}
+			if err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: source.ConfigMap.Name}, configMap); err != nil {
+				return nil, err
+			}
+			prefix = "configmap/" + configMap.Name + "/"
+			data = configMap.Data
+		case source.Secret != nil:
+			secret := &corev1.Secret{}
+			if err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: source.Secret.Name}, secret); err != nil {
+				return nil, err
+			}
+			prefix = "secret/" + secret.Name + "/"
+			for key, value := range secret.Data {
+				data[key] = string(value)
+			}
+		}
+
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			if strings.HasSuffix(key, ".sql") {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			scripts = append(scripts, initScript{name: prefix + key, sql: data[key]})
+		}
+	}
+	return scripts, nil
+}
+
+// initScriptsPending returns true until every script of spec.initScripts the
+// instance was created with succeeded
+func initScriptsPending(singleGreatsql *singlev1.Single) bool {
+	return len(singleGreatsql.Spec.InitScripts) > 0 && singleGreatsql.Status.InitScripts != nil &&
+		!meta.IsStatusConditionTrue(singleGreatsql.Status.Conditions, singlev1.ConditionInitScripts)
+}
+
+// newInitScriptsStatus returns the status recorded when the workload and its new
+// datadir are created, nil without scripts
+func newInitScriptsStatus(singleGreatsql *singlev1.Single) *singlev1.InitScriptsStatus {
+	if len(singleGreatsql.Spec.InitScripts) == 0 {
+		return nil
+	}
+	return &singlev1.InitScriptsStatus{}
+}
+
+// gateReady sets the Ready condition and returns the ready servers to report. The
+// instance is not ready for clients until its init scripts ran.
+func gateReady(singleGreatsql *singlev1.Single, ready int32) int32 {
+	switch {
+	case initScriptsPending(singleGreatsql):
+		setCondition(singleGreatsql, singlev1.ConditionReady, metav1.ConditionFalse, "InitScriptsPending", "waiting for spec.initScripts to run")
+		return 0
+	case ready == 0:
+		setCondition(singleGreatsql, singlev1.ConditionReady, metav1.ConditionFalse, "NotReady", "no server is ready")
+	default:
+		setCondition(singleGreatsql, singlev1.ConditionReady, metav1.ConditionTrue, "Ready", fmt.Sprintf("%d servers are ready", ready))
+	}
+	return ready
+}
+
+// initScriptsChecksum returns the sha256 of the names and contents of the scripts
+func initScriptsChecksum(scripts []initScript) string {
+	hash := sha256.New()
+	for _, script := range scripts {
+		hash.Write([]byte(script.name))
+		hash.Write([]byte{0})
+		hash.Write([]byte(script.sql))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-21 15:10:27
+ * @file: maintenance.go
+ * @description: maintenance windows for disruptive changes
+ */
+
+// maintenanceWindowOpen returns true while disruptive changes may be applied:
+// no window is set, one of them is open or the apply annotation forces them
+func maintenanceWindowOpen(singleGreatsql *singlev1.Single, now time.Time) bool {
+	if len(singleGreatsql.Spec.MaintenanceWindows) == 0 || singleGreatsql.Annotations[consts.ApplyPendingChanges] != "" {
+		return true
+	}
+	for _, window := range singleGreatsql.Spec.MaintenanceWindows {
+		if open, _, err := windowOccurrence(window, now); err == nil && open {
+			return true
+		}
+	}
+	return false
+}
+
+// nextMaintenanceWindow returns when the next maintenance window opens, zero if none does
+func nextMaintenanceWindow(singleGreatsql *singlev1.Single, now time.Time) time.Time {
+	var next time.Time
+	for _, window := range singleGreatsql.Spec.MaintenanceWindows {
+		_, opens, err := windowOccurrence(window, now)
+		if err != nil || opens.IsZero() {
+			continue
+		}
+		if next.IsZero() || opens.Before(next) {
+			next = opens
+		}
+	}
+	return next
+}
+
+// windowOccurrence returns true if t is inside the window, and when the window
+// opened or opens next. Start and end are wall clock times of the time zone.
+func windowOccurrence(window singlev1.MaintenanceWindow, t time.Time) (bool, time.Time, error) {
+	location := time.UTC
+	if window.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(window.TimeZone); err != nil {
+			return false, time.Time{}, err
+		}
+	}
+	start, err := time.Parse("15:04", window.Start)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("start: %w", err)
+	}
+	end, err := time.Parse("15:04", window.End)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("end: %w", err)
+	}
+
+	// a window closing after midnight may have opened the day before
+	local := t.In(location)
+	for day := -1; day <= 7; day++ {
+		date := time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, location)
+		if !windowDay(window, date.Weekday()) {
+			continue
+		}
+		opens := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, location)
+		closes := time.Date(date.Year(), date.Month(), date.Day(), end.Hour(), end.Minute(), 0, 0, location)
+		if !closes.After(opens) {
+			closes = time.Date(date.Year(), date.Month(), date.Day()+1, end.Hour(), end.Minute(), 0, 0, location)
+		}
+		if !t.Before(opens) && t.Before(closes) {
+			return true, opens, nil
+		}
+		if opens.After(t) {
+			return false, opens, nil
+		}
+	}
+	return false, time.Time{}, nil
+}
+
+// windowDay returns true if the window opens on the weekday
+func windowDay(window singlev1.MaintenanceWindow, weekday time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, day := range window.Days {
+		if string(day) == weekday.String()[:3] {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcilePendingRestart records the disruptive changes waiting for a maintenance
+// window in the PendingRestart condition and removes the apply annotation once
+// nothing is pending. It returns how long until the next window opens while
+// changes wait for it.
+func (r *SingleReconciler) reconcilePendingRestart(ctx context.Context, singleGreatsql *singlev1.Single, pending []string) (time.Duration, error) {
+	conditions := append([]metav1.Condition(nil), singleGreatsql.Status.Conditions...)
+	now := time.Now()
+	var wait time.Duration
+
+	switch {
+	case len(pending) == 0:
+		if _, found := singleGreatsql.Annotations[consts.ApplyPendingChanges]; found {
+			delete(singleGreatsql.Annotations, consts.ApplyPendingChanges)
+			if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+				return 0, err
+			}
+		}
+		if condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionPendingRestart); condition != nil && condition.Status == metav1.ConditionTrue {
+			setCondition(singleGreatsql, singlev1.ConditionPendingRestart, metav1.ConditionFalse, "Applied", "no disruptive change is pending")
+		}
+	case maintenanceWindowOpen(singleGreatsql, now):
+		setCondition(singleGreatsql, singlev1.ConditionPendingRestart, metav1.ConditionTrue, "Applying", "applying "+strings.Join(pending, ", "))
+	default:
+		message := strings.Join(pending, ", ") + " waiting for a maintenance window"
+		if next := nextMaintenanceWindow(singleGreatsql, now); !next.IsZero() {
+			message += " opening at " + next.Format(time.RFC3339)
+			wait = next.Sub(now)
+		}
+		setCondition(singleGreatsql, singlev1.ConditionPendingRestart, metav1.ConditionTrue, "WaitingForMaintenanceWindow", message)
+	}
+
+	if equality.Semantic.DeepEqual(conditions, singleGreatsql.Status.Conditions) {
+		return wait, nil
+	}
+	return wait, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// pendingClusterChanges returns the disruptive changes the statefulset and its
+// members did not take yet
+func (r *SingleReconciler) pendingClusterChanges(ctx context.Context, singleGreatsql *singlev1.Single) ([]string, error) {
+	statefulSet := kube.NewStatefulSet(singleGreatsql, singleGreatsql.Name+"-config")
+	oldStatefulSet := &appsv1.StatefulSet{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(statefulSet), oldStatefulSet); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	var pending []string
+	if oldStatefulSet.Annotations[consts.TemplateHash] != statefulSet.Annotations[consts.TemplateHash] {
+		pending = append(pending, "pod template change")
+	}
+	if claimTemplateGrown(oldStatefulSet, statefulSet) {
+		pending = append(pending, "statefulset recreation for the grown claim template")
+	}
+
+	revision := oldStatefulSet.Status.UpdateRevision
+	if revision == "" {
+		return pending, nil
+	}
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return nil, err
+	}
+	held, _ := catchUpRequest(singleGreatsql)
+	outdated := 0
+	for _, pod := range pods.Items {
+		if pod.Name != held && pod.Labels[appsv1.StatefulSetRevisionLabel] != revision {
+			outdated++
+		}
+	}
+	if outdated > 0 {
+		pending = append(pending, fmt.Sprintf("restart of %d members", outdated))
+	}
+	return pending, nil
+}
+
+// pendingSingleChanges returns the disruptive changes the deployment did not take yet
+func (r *SingleReconciler) pendingSingleChanges(ctx context.Context, singleGreatsql *singlev1.Single) ([]string, error) {
+	deployment := kube.NewDeployment(singleGreatsql, singleGreatsql.Name+"-config")
+	oldDeployment := &appsv1.Deployment{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(deployment), oldDeployment); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if oldDeployment.Annotations[consts.TemplateHash] != deployment.Annotations[consts.TemplateHash] {
+		return []string{"pod template change"}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+)
+
+func TestWindowOccurrence(t *testing.T) {
+	// 2026-10-19 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+	overnight := singlev1.MaintenanceWindow{Days: []singlev1.Weekday{"Mon"}, Start: "22:00", End: "02:00"}
+	weekend := singlev1.MaintenanceWindow{Days: []singlev1.Weekday{"Sat", "Sun"}, Start: "01:00", End: "05:00"}
+	daily := singlev1.MaintenanceWindow{Start: "01:00", End: "05:00"}
+	shanghai := singlev1.MaintenanceWindow{Days: []singlev1.Weekday{"Tue"}, Start: "02:00", End: "04:00", TimeZone: "Asia/Shanghai"}
+
+	cases := []struct {
+		name   string
+		window singlev1.MaintenanceWindow
+		t      time.Time
+		open   bool
+		opens  time.Time
+	}{
+		{"before an overnight window", overnight, at(19, 21, 0), false, at(19, 22, 0)},
+		{"overnight window before midnight", overnight, at(19, 23, 0), true, at(19, 22, 0)},
+		{"overnight window after midnight", overnight, at(20, 1, 30), true, at(19, 22, 0)},
+		{"overnight window closed", overnight, at(20, 2, 0), false, at(26, 22, 0)},
+		{"weekday filtered", weekend, at(19, 3, 0), false, at(24, 1, 0)},
+		{"weekend window open", weekend, at(25, 4, 59), true, at(25, 1, 0)},
+		{"every day", daily, at(19, 6, 0), false, at(20, 1, 0)},
+		// 18:30 UTC on Monday is 02:30 on Tuesday in Shanghai
+		{"time zone open", shanghai, at(19, 18, 30), true, at(19, 18, 0)},
+		{"time zone before", shanghai, at(19, 17, 0), false, at(19, 18, 0)},
+		{"time zone weekday", shanghai, at(20, 18, 30), false, at(26, 18, 0)},
+	}
+	for _, c := range cases {
+		open, opens, err := windowOccurrence(c.window, c.t)
+		if err != nil {
+			t.Errorf("%s: windowOccurrence: %v", c.name, err)
+			continue
+		}
+		if open != c.open || !opens.Equal(c.opens) {
+			t.Errorf("%s: windowOccurrence(%s) = %v, %s, want %v, %s", c.name, c.t, open, opens.UTC(), c.open, c.opens)
+		}
+	}
+
+	invalid := []singlev1.MaintenanceWindow{
+		{Start: "01:00", End: "05:00", TimeZone: "Mars/Olympus"},
+		{Start: "1am", End: "05:00"},
+		{Start: "01:00", End: "25:00"},
+	}
+	for _, window := range invalid {
+		if _, _, err := windowOccurrence(window, at(19, 0, 0)); err == nil {
+			t.Errorf("windowOccurrence(%+v): expected an error", window)
+		}
+	}
+}
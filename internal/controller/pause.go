@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-21 17:36:12
+ * @file: pause.go
+ * @description: paused and stopped instances
+ */
+
+// reconcilePaused observes a paused instance without changing it. It returns
+// true while the instance is paused, nothing else runs against it then.
+func (r *SingleReconciler) reconcilePaused(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionPaused)
+	if !singleGreatsql.Spec.Paused {
+		if condition == nil || condition.Status == metav1.ConditionFalse {
+			return false, nil
+		}
+		logger.Info("Reconciliation resumed", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+		setCondition(singleGreatsql, singlev1.ConditionPaused, metav1.ConditionFalse, "Resumed", "the operator manages the instance again")
+		return false, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+
+	if condition == nil || condition.Status == metav1.ConditionFalse {
+		logger.Info("Reconciliation paused", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+	}
+	setCondition(singleGreatsql, singlev1.ConditionPaused, metav1.ConditionTrue, "Paused", "the operator changes nothing until spec.paused is unset")
+
+	if !singleGreatsql.Spec.IsCluster() {
+		deployment := &appsv1.Deployment{}
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(singleGreatsql), deployment); client.IgnoreNotFound(err) != nil {
+			return true, err
+		}
+		singleGreatsql.Status.Ready = deployment.Status.ReadyReplicas
+		return true, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+
+	rootPassword, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	members, err := r.observeMembers(ctx, singleGreatsql, rootPassword)
+	if err != nil {
+		return true, err
+	}
+	defer closeMembers(members)
+
+	// the roles are the ones the operator labeled before it was paused
+	for _, m := range members {
+		m.role = singlev1.MemberRole(m.pod.Labels[consts.GreatSqlRole])
+	}
+	return true, r.updateClusterStatus(ctx, singleGreatsql, members, findMember(members, singleGreatsql.Status.Primary))
+}
+
+// reconcileStopped records the shutdown of a stopped instance, the deployment or
+// statefulset is scaled to zero with the spec. It returns true while the instance
+// is stopped, nothing else runs against it then.
+func (r *SingleReconciler) reconcileStopped(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionStopped)
+	if !singleGreatsql.Spec.Stopped {
+		if condition == nil || condition.Reason == "Started" {
+			return false, nil
+		}
+		logger.Info("Starting stopped instance", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+		setCondition(singleGreatsql, singlev1.ConditionStopped, metav1.ConditionFalse, "Started", "the servers were started again")
+		r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Started", "the servers were started again")
+		return false, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+
+	// mysqld shuts down cleanly on SIGTERM within the termination grace period
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return true, err
+	}
+	if len(pods.Items) > 0 {
+		setCondition(singleGreatsql, singlev1.ConditionStopped, metav1.ConditionFalse, "Stopping", fmt.Sprintf("waiting for %d pods to shut down", len(pods.Items)))
+	} else if condition == nil || condition.Status == metav1.ConditionFalse {
+		logger.Info("Instance stopped", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+		setCondition(singleGreatsql, singlev1.ConditionStopped, metav1.ConditionTrue, "Stopped", "every server is shut down, the volumes are kept")
+		r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Stopped", "every server is shut down, the volumes are kept")
+	}
+
+	// the primary stays recorded, it is the first candidate after the start
+	singleGreatsql.Status.Size = int32(len(pods.Items))
+	singleGreatsql.Status.Selector = labels.SelectorFromSet(kube.NewLabels(singleGreatsql)).String()
+	singleGreatsql.Status.Ready = 0
+	singleGreatsql.Status.Members = nil
+	singleGreatsql.Status.Volumes = nil
+	return true, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// scaleDeployment keeps the replicas of the deployment of a single in line with
+// spec.stopped, before anything else waits for its pod
+func (r *SingleReconciler) scaleDeployment(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	desired := kube.NewDeployment(singleGreatsql, singleGreatsql.Name+"-config")
+	deployment := &appsv1.Deployment{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(desired), deployment); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == *desired.Spec.Replicas {
+		return nil
+	}
+	deployment.Spec.Replicas = desired.Spec.Replicas
+	return r.Client.Update(ctx, deployment)
+}
@@ -0,0 +1,610 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 13:05:17
+ * @file: replication.go
+ * @description: replicaofCluster topology, failover and switchover
+ */
+
+const (
+	// catchUpTimeout bounds how long a promotion waits for relay logs to be applied
+	catchUpTimeout = 20 * time.Second
+)
+
+// member is the observed state of one statefulset pod
+type member struct {
+	pod     *corev1.Pod
+	ordinal int32
+	db      *greatsql.Client // nil when the server can not be reached
+	gtid    greatsql.GTIDSet
+	replica *greatsql.ReplicaStatus
+	state   string
+	role    singlev1.MemberRole // empty while the member must not receive traffic
+}
+
+// reachable returns true if the server answered the health check
+func (m *member) reachable() bool {
+	return m != nil && m.db != nil
+}
+
+// failed returns true if both the pod is not ready and the server does not answer
+func (m *member) failed() bool {
+	return m == nil || (!podReady(m.pod) && !m.reachable())
+}
+
+// reconcileReplication bootstraps the source/replica topology, replaces a failed
+// primary, runs planned switchovers and keeps every replica pointed at the primary
+func (r *SingleReconciler) reconcileReplication(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	rootPassword, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	account, err := r.replicationCredentials(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+
+	members, err := r.observeMembers(ctx, singleGreatsql, rootPassword)
+	if err != nil {
+		return err
+	}
+	defer closeMembers(members)
+
+	primary := findMember(members, currentPrimary(singleGreatsql, members))
+	switch {
+	case primary == nil && singleGreatsql.Status.Primary == "":
+		// first start, promote the most advanced member once the first ordinal is up
+		primary = bootstrapCandidate(members)
+		if primary == nil {
+			log.Info("Waiting for members to start before bootstrapping")
+			return r.updateClusterStatus(ctx, singleGreatsql, members, nil)
+		}
+		// a standby starts from a copy of its primary instance
+		if seeded, err := r.seedStandby(ctx, singleGreatsql, primary, rootPassword); err != nil || !seeded {
+			if updateErr := r.updateClusterStatus(ctx, singleGreatsql, members, nil); updateErr != nil {
+				return updateErr
+			}
+			return err
+		}
+		if err := promote(ctx, primary); err != nil {
+			return err
+		}
+		log.Info("Bootstrapped replication", "primary", primary.pod.Name)
+
+	case primary.failed():
+		primary, err = r.handlePrimaryFailure(ctx, singleGreatsql, members, primary)
+		if err != nil || primary == nil {
+			if updateErr := r.updateClusterStatus(ctx, singleGreatsql, members, primary); updateErr != nil {
+				return updateErr
+			}
+			return err
+		}
+
+	default:
+		if target := singleGreatsql.Annotations[consts.SwitchoverTarget]; target != "" {
+			primary = r.switchover(ctx, singleGreatsql, members, primary, target)
+		}
+		setCondition(singleGreatsql, singlev1.ConditionPrimaryHealthy, metav1.ConditionTrue, "Healthy", "primary "+primary.pod.Name+" is healthy")
+		if err := unfencePrimary(ctx, singleGreatsql, primary); err != nil {
+			return err
+		}
+	}
+
+	if primary, err = r.scaleInReplication(ctx, singleGreatsql, members, primary); err != nil {
+		log.Error(err, "Could not scale in")
+	}
+	if err := r.reconcileStandby(ctx, singleGreatsql, members, primary); err != nil {
+		log.Error(err, "Could not replicate from the primary instance")
+	}
+
+	if primary.reachable() {
+		if err := primary.db.EnsureReplicationUser(ctx, account.user, account.password, kube.MutualTLS(singleGreatsql)); err != nil {
+			return err
+		}
+		if err := primary.db.InstallClonePlugin(ctx); err != nil {
+			return err
+		}
+		source := greatsql.ReplicationSource{
+			Host:     kube.PodFQDN(singleGreatsql, primary.pod.Name),
+			Port:     greatsql.DefaultPort,
+			User:     account.user,
+			Password: account.password,
+		}
+		for _, m := range members {
+			if m == primary || !m.reachable() || departing(singleGreatsql, m) {
+				continue
+			}
+			// a delayed replica rolled forward is held where it stopped
+			if held, err := r.reconcileCatchUp(ctx, singleGreatsql, m); err != nil || held {
+				if err != nil {
+					log.Error(err, "Could not catch up delayed replica", "member", m.pod.Name)
+				}
+				continue
+			}
+
+			// new members are cloned when the binlogs can not bring them up to date
+			// the member connects with its own certificate
+			memberSource := source
+			memberSource.TLS = memberTLS(singleGreatsql, m.pod.Name)
+			seed, err := needsSeed(ctx, m, primary)
+			if err == nil && (seed || isSeeding(m)) {
+				seedMember(m, rootPassword, memberSource)
+				m.state = singlev1.MemberStateRecovering
+				continue
+			}
+			if err == nil {
+				memberSource.Delay = replicationDelay(singleGreatsql, m)
+				err = configureReplica(ctx, m, primary, memberSource)
+			}
+			if err != nil {
+				log.Error(err, "Could not configure replica", "member", m.pod.Name)
+				m.state = singlev1.MemberStateError
+			}
+		}
+	}
+
+	resumeCatchUp(singleGreatsql)
+
+	// members on an outdated template are restarted one at a time
+	healthy := func(m *member) bool {
+		return podReady(m.pod) && m.reachable() && (m == primary || m.replica.Running())
+	}
+	switchover := func(candidate *member) error {
+		if err := switchPrimary(ctx, primary, candidate); err != nil {
+			return err
+		}
+		primary = candidate
+		return nil
+	}
+	if err := r.rollMembers(ctx, singleGreatsql, members, primary, healthy, switchover); err != nil {
+		log.Error(err, "Could not roll out members")
+	}
+
+	for _, m := range members {
+		switch {
+		case m == primary:
+			m.role = singlev1.PrimaryRole
+		case departing(singleGreatsql, m) || m.state == singlev1.MemberStateRecovering:
+			m.role = ""
+		case delayed(singleGreatsql, m):
+			// the read service must not serve stale data
+			m.role = singlev1.DelayedRole
+		default:
+			m.role = singlev1.ReplicaofRole
+		}
+	}
+	if err := r.labelMembers(ctx, members); err != nil {
+		return err
+	}
+
+	return r.updateClusterStatus(ctx, singleGreatsql, members, primary)
+}
+
+// handlePrimaryFailure records the failure and, once the grace period is over,
+// promotes the most advanced replica. It returns the primary to use from now on,
+// nil while there is none.
+func (r *SingleReconciler) handlePrimaryFailure(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, primary *member) (*member, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	setCondition(singleGreatsql, singlev1.ConditionPrimaryHealthy, metav1.ConditionFalse, "Unhealthy", "primary "+singleGreatsql.Status.Primary+" failed its health checks")
+	if singleGreatsql.Spec.Failover.Disabled {
+		return nil, nil
+	}
+
+	unhealthySince := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionPrimaryHealthy).LastTransitionTime
+	gracePeriod := time.Duration(singleGreatsql.Spec.Failover.GracePeriodSeconds) * time.Second
+	if time.Since(unhealthySince.Time) < gracePeriod {
+		return nil, nil
+	}
+
+	candidate := failoverCandidate(singleGreatsql, members, primary)
+	if candidate == nil {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "NoCandidate", "no reachable replica to fail over to")
+		return nil, fmt.Errorf("primary %s failed and no replica is reachable", singleGreatsql.Status.Primary)
+	}
+
+	// apply what was already received before taking writes
+	if candidate.replica != nil {
+		if received, err := greatsql.ParseGTIDSet(candidate.replica.RetrievedGTIDSet); err == nil {
+			if _, err := candidate.db.WaitForExecutedGTIDSet(ctx, candidate.gtid.Union(received), catchUpTimeout); err != nil {
+				log.Error(err, "Could not wait for relay logs to be applied", "member", candidate.pod.Name)
+			}
+		}
+	}
+
+	if err := promote(ctx, candidate); err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "FailoverFailed", err.Error())
+		return nil, err
+	}
+
+	log.Info("Failed over to new primary", "old", singleGreatsql.Status.Primary, "new", candidate.pod.Name)
+	setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionTrue, "FailedOver", "promoted "+candidate.pod.Name+" after "+singleGreatsql.Status.Primary+" failed")
+	setCondition(singleGreatsql, singlev1.ConditionPrimaryHealthy, metav1.ConditionTrue, "Healthy", "primary "+candidate.pod.Name+" is healthy")
+	return candidate, nil
+}
+
+// switchover hands the primary role to target, the old primary becomes a replica.
+// It returns the primary after the attempt, which is the old one on failure.
+func (r *SingleReconciler) switchover(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, primary *member, target string) *member {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	// the request is consumed whatever the outcome, the condition tells what happened
+	delete(singleGreatsql.Annotations, consts.SwitchoverTarget)
+	if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not clear switchover annotation")
+		return primary
+	}
+
+	candidate := findMember(members, target)
+	if !primary.reachable() {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "PrimaryUnreachable", "primary "+primary.pod.Name+" does not answer")
+		return primary
+	}
+	if err := validateSwitchoverTarget(singleGreatsql, candidate, primary, target); err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "InvalidTarget", err.Error())
+		return primary
+	}
+
+	if err := switchPrimary(ctx, primary, candidate); err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionFalse, "SwitchoverFailed", err.Error())
+		return primary
+	}
+
+	log.Info("Switched over to new primary", "old", primary.pod.Name, "new", target)
+	setCondition(singleGreatsql, singlev1.ConditionSwitchover, metav1.ConditionTrue, "SwitchedOver", "promoted "+target+", "+primary.pod.Name+" is now a replica")
+	return candidate
+}
+
+// switchPrimary fences the primary, waits for candidate to apply every
+// transaction and promotes it. The old primary is made writable again on failure.
+func switchPrimary(ctx context.Context, primary, candidate *member) error {
+	if err := primary.db.SetReadOnly(ctx, true); err != nil {
+		return err
+	}
+
+	// nothing can be written anymore, wait until the candidate has every transaction
+	gtid, err := primary.db.GTIDExecuted(ctx)
+	if err == nil {
+		var caughtUp bool
+		if caughtUp, err = candidate.db.WaitForExecutedGTIDSet(ctx, gtid, catchUpTimeout); err == nil && !caughtUp {
+			err = fmt.Errorf("%s did not catch up with %s within %s", candidate.pod.Name, primary.pod.Name, catchUpTimeout)
+		}
+	}
+	if err == nil {
+		err = promote(ctx, candidate)
+	}
+	if err != nil {
+		if rollbackErr := primary.db.Unfence(ctx); rollbackErr != nil {
+			logger.Error(rollbackErr, "Could not make old primary writable again", "member", primary.pod.Name)
+		}
+		return err
+	}
+
+	// the old primary has no replication configured, force configureReplica to set it up
+	primary.replica = nil
+	primary.gtid = gtid
+	return nil
+}
+
+// validateSwitchoverTarget checks the target can take over without losing data
+func validateSwitchoverTarget(singleGreatsql *singlev1.Single, candidate, primary *member, target string) error {
+	switch {
+	case candidate == nil:
+		return fmt.Errorf("%s is not a member of the cluster", target)
+	case candidate == primary:
+		return fmt.Errorf("%s is already the primary", target)
+	case delayed(singleGreatsql, candidate):
+		return fmt.Errorf("%s is a delayed replica", target)
+	case !podReady(candidate.pod) || !candidate.reachable():
+		return fmt.Errorf("%s is not healthy", target)
+	case !candidate.replica.Running():
+		return fmt.Errorf("%s is not replicating", target)
+	case !primary.gtid.Contains(candidate.gtid):
+		return fmt.Errorf("%s has transactions the primary does not have", target)
+	}
+	return nil
+}
+
+// configureReplica makes m a read only replica of primary
+func configureReplica(ctx context.Context, m, primary *member, source greatsql.ReplicationSource) error {
+	// a member with transactions the primary does not have would silently diverge
+	if !primary.gtid.Contains(m.gtid) {
+		return fmt.Errorf("member has errant transactions not present on %s", primary.pod.Name)
+	}
+
+	if readOnly, err := m.db.IsReadOnly(ctx); err != nil || !readOnly {
+		if err := m.db.SetReadOnly(ctx, true); err != nil {
+			return err
+		}
+	}
+
+	if m.replica != nil && m.replica.SourceHost == source.Host && m.replica.SQLDelay == source.Delay && m.replica.SSLAllowed == (source.TLS != nil) {
+		if m.replica.Running() {
+			return nil
+		}
+		return m.db.StartReplica(ctx)
+	}
+
+	if m.replica != nil {
+		if err := m.db.StopReplica(ctx); err != nil {
+			return err
+		}
+	}
+	if err := m.db.ChangeReplicationSource(ctx, source); err != nil {
+		return err
+	}
+	return m.db.StartReplica(ctx)
+}
+
+// promote turns a replica into a primary, writable until it restarts
+func promote(ctx context.Context, m *member) error {
+	if m.replica != nil {
+		if err := m.db.StopReplica(ctx); err != nil {
+			return err
+		}
+		if err := m.db.ResetReplicaAll(ctx); err != nil {
+			return err
+		}
+		m.replica = nil
+	}
+	return m.db.Unfence(ctx)
+}
+
+// unfencePrimary makes the elected primary writable again after it restarted,
+// every member starts read only. A standby stays read only until it is promoted.
+func unfencePrimary(ctx context.Context, singleGreatsql *singlev1.Single, primary *member) error {
+	if !primary.reachable() || (singleGreatsql.Spec.IsStandby() && !standbyPromoted(singleGreatsql)) {
+		return nil
+	}
+	readOnly, err := primary.db.IsReadOnly(ctx)
+	if err != nil || !readOnly {
+		return err
+	}
+	logger.Info("Unfencing restarted primary", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "member", primary.pod.Name)
+	return primary.db.Unfence(ctx)
+}
+
+// observeMembers connects to every pod of the statefulset and reads its state
+func (r *SingleReconciler) observeMembers(ctx context.Context, singleGreatsql *singlev1.Single, password string) ([]*member, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return nil, err
+	}
+
+	members := make([]*member, 0, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		m := &member{pod: pod, ordinal: podOrdinal(pod.Name), state: singlev1.MemberStateUnreachable}
+		members = append(members, m)
+
+		if pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		// a paused instance is only observed, its server_id is left alone
+		serverID := memberServerID(singleGreatsql, m.ordinal)
+		if singleGreatsql.Spec.Paused {
+			serverID = 0
+		}
+		if err := m.connect(ctx, password, serverID); err != nil {
+			log.Info("Member is unreachable", "member", pod.Name, "error", err.Error())
+			continue
+		}
+		m.state = singlev1.MemberStateOnline
+		if m.replica != nil && (m.replica.LastIOError != "" || m.replica.LastSQLError != "") {
+			m.state = singlev1.MemberStateError
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].ordinal < members[j].ordinal })
+	return members, nil
+}
+
+// connect opens the sql connection and reads the replication state, a serverID
+// of 0 does not set server_id
+func (m *member) connect(ctx context.Context, password string, serverID int32) error {
+	db, err := greatsql.NewClient(m.pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return err
+	}
+
+	err = db.Ping(ctx)
+	if err == nil && serverID != 0 {
+		// server_id must be unique across the cluster for replication to work
+		err = db.SetServerID(ctx, serverID)
+	}
+	if err == nil {
+		m.gtid, err = db.GTIDExecuted(ctx)
+	}
+	if err == nil {
+		m.replica, err = db.ReplicaStatus(ctx)
+	}
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	m.db = db
+	return nil
+}
+
+// labelMembers sets the role label the services select on, members without a
+// role lose the label so no service routes to them
+func (r *SingleReconciler) labelMembers(ctx context.Context, members []*member) error {
+	for _, m := range members {
+		role, labeled := m.pod.Labels[consts.GreatSqlRole]
+		if role == string(m.role) && (labeled || m.role == "") {
+			continue
+		}
+
+		patch := client.MergeFrom(m.pod.DeepCopy())
+		if m.role == "" {
+			delete(m.pod.Labels, consts.GreatSqlRole)
+		} else {
+			if m.pod.Labels == nil {
+				m.pod.Labels = map[string]string{}
+			}
+			m.pod.Labels[consts.GreatSqlRole] = string(m.role)
+		}
+		if err := r.Client.Patch(ctx, m.pod, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateClusterStatus records the members and the primary in the status
+func (r *SingleReconciler) updateClusterStatus(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, primary *member) error {
+	status := &singleGreatsql.Status
+	// the scale subresource reports the members that exist, not the desired size
+	status.Size = int32(len(members))
+	status.Selector = labels.SelectorFromSet(kube.NewLabels(singleGreatsql)).String()
+	status.Ready = 0
+	status.Members = status.Members[:0]
+	if primary != nil {
+		status.Primary = primary.pod.Name
+	}
+
+	for _, m := range members {
+		if m.state == singlev1.MemberStateOnline {
+			status.Ready++
+		}
+		status.Members = append(status.Members, singlev1.MemberStatus{
+			Name:         m.pod.Name,
+			Role:         m.role,
+			State:        m.state,
+			GtidExecuted: m.gtid.String(),
+		})
+	}
+	status.Ready = gateReady(singleGreatsql, status.Ready)
+
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// currentPrimary returns the primary recorded in the status, falling back to the
+// pod carrying the primary label
+func currentPrimary(singleGreatsql *singlev1.Single, members []*member) string {
+	if singleGreatsql.Status.Primary != "" {
+		return singleGreatsql.Status.Primary
+	}
+	for _, m := range members {
+		if m.pod.Labels[consts.GreatSqlRole] == string(singlev1.PrimaryRole) {
+			return m.pod.Name
+		}
+	}
+	return ""
+}
+
+// bootstrapCandidate returns the most advanced reachable member, nil until the
+// first ordinal is reachable so a fresh cluster always starts from it
+func bootstrapCandidate(members []*member) *member {
+	if len(members) == 0 || members[0].ordinal != 0 || !members[0].reachable() {
+		return nil
+	}
+	return mostAdvanced(members, nil)
+}
+
+// failoverCandidate returns the reachable replica with the most advanced gtid
+// set, delayed replicas are never promoted
+func failoverCandidate(singleGreatsql *singlev1.Single, members []*member, primary *member) *member {
+	candidates := make([]*member, 0, len(members))
+	for _, m := range members {
+		if !delayed(singleGreatsql, m) {
+			candidates = append(candidates, m)
+		}
+	}
+	return mostAdvanced(candidates, primary)
+}
+
+// mostAdvanced compares executed plus received transactions of the reachable members except skip
+func mostAdvanced(members []*member, skip *member) *member {
+	sets := map[string]greatsql.GTIDSet{}
+	for _, m := range members {
+		if m == skip || !m.reachable() {
+			continue
+		}
+		sets[m.pod.Name] = m.gtid
+		if m.replica != nil {
+			if received, err := greatsql.ParseGTIDSet(m.replica.RetrievedGTIDSet); err == nil {
+				sets[m.pod.Name] = m.gtid.Union(received)
+			}
+		}
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	return findMember(members, greatsql.MostAdvanced(sets))
+}
+
+// findMember returns the member running in the named pod
+func findMember(members []*member, name string) *member {
+	for _, m := range members {
+		if m.pod.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// closeMembers closes the open sql connections
+func closeMembers(members []*member) {
+	for _, m := range members {
+		if m.reachable() {
+			m.db.Close()
+		}
+	}
+}
+
+// podOrdinal returns the statefulset ordinal from the pod name
+func podOrdinal(name string) int32 {
+	ordinal, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	if err != nil {
+		return -1
+	}
+	return int32(ordinal)
+}
+
+// podReady returns true if the pod passed its readiness probe
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// setCondition sets a status condition of the single
+func setCondition(singleGreatsql *singlev1.Single, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&singleGreatsql.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: singleGreatsql.Generation,
+	})
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 20:05:36
+ * @file: scale.go
+ * @description: member aware scale in
+ */
+
+// departing returns true for members a pending scale in removes, the
+// statefulset always removes the highest ordinals first
+func departing(singleGreatsql *singlev1.Single, m *member) bool {
+	return m.ordinal >= singleGreatsql.Spec.GetSize()
+}
+
+// scaleInReplication moves the primary role off the departing members and stops
+// replication on them. It returns the primary to use from now on.
+func (r *SingleReconciler) scaleInReplication(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, primary *member) (*member, error) {
+	if !scalingIn(singleGreatsql, members) {
+		return primary, nil
+	}
+
+	if primary != nil && departing(singleGreatsql, primary) {
+		var candidate *member
+		for _, m := range members {
+			if !departing(singleGreatsql, m) && !delayed(singleGreatsql, m) && m.reachable() && m.replica.Running() && (candidate == nil || m.gtid.Contains(candidate.gtid)) {
+				candidate = m
+			}
+		}
+		if candidate == nil {
+			err := fmt.Errorf("primary %s is removed by the scale in but no remaining member can take over", primary.pod.Name)
+			setCondition(singleGreatsql, singlev1.ConditionScaling, metav1.ConditionFalse, "NoCandidate", err.Error())
+			return primary, err
+		}
+		if err := switchPrimary(ctx, primary, candidate); err != nil {
+			setCondition(singleGreatsql, singlev1.ConditionScaling, metav1.ConditionFalse, "SwitchoverFailed", err.Error())
+			return primary, err
+		}
+		logger.Info("Moved primary off departing member", "old", primary.pod.Name, "new", candidate.pod.Name)
+		primary = candidate
+	}
+
+	for _, m := range members {
+		if !departing(singleGreatsql, m) || !m.reachable() || m.replica == nil {
+			continue
+		}
+		if err := m.db.StopReplica(ctx); err != nil {
+			return primary, err
+		}
+		if err := m.db.ResetReplicaAll(ctx); err != nil {
+			return primary, err
+		}
+		m.replica = nil
+	}
+
+	return primary, r.completeScaleIn(ctx, singleGreatsql)
+}
+
+// scaleInGroup hands the primary role to a remaining member and makes the
+// departing members leave the group
+func (r *SingleReconciler) scaleInGroup(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, view groupView) error {
+	if !scalingIn(singleGreatsql, members) {
+		return nil
+	}
+
+	for _, m := range members {
+		if !departing(singleGreatsql, m) || view[m.pod.Name].Role != groupRolePrimary || singleGreatsql.Spec.GreatSqlType != singlev1.GreatSqlTypeSinglePrimaryGroupCluster {
+			continue
+		}
+
+		var err error = fmt.Errorf("primary %s is removed by the scale in but no remaining member is ONLINE", m.pod.Name)
+		for _, candidate := range members {
+			if departing(singleGreatsql, candidate) || !candidate.reachable() || view[candidate.pod.Name].State != singlev1.MemberStateOnline {
+				continue
+			}
+			if err = candidate.db.SetAsPrimary(ctx, view[candidate.pod.Name].ID); err == nil {
+				logger.Info("Moved primary off departing member", "old", m.pod.Name, "new", candidate.pod.Name)
+				break
+			}
+		}
+		if err != nil {
+			setCondition(singleGreatsql, singlev1.ConditionScaling, metav1.ConditionFalse, "NoCandidate", err.Error())
+			return err
+		}
+	}
+
+	for _, m := range members {
+		if !departing(singleGreatsql, m) || !m.reachable() || m.state == singlev1.MemberStateOffline {
+			continue
+		}
+		if err := m.db.StopGroupReplication(ctx); err != nil {
+			return err
+		}
+		m.state = singlev1.MemberStateOffline
+	}
+
+	return r.completeScaleIn(ctx, singleGreatsql)
+}
+
+// completeScaleIn lowers the statefulset replicas once the departing members
+// left the topology, the claim retention policy decides about their volumes
+func (r *SingleReconciler) completeScaleIn(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(singleGreatsql), statefulSet); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(statefulSet.DeepCopy())
+	statefulSet.Spec.Replicas = singleGreatsql.Spec.Size
+	if err := r.Client.Patch(ctx, statefulSet, patch); err != nil {
+		return err
+	}
+
+	setCondition(singleGreatsql, singlev1.ConditionScaling, metav1.ConditionTrue, "ScaledIn", fmt.Sprintf("scaled in to %d members", singleGreatsql.Spec.GetSize()))
+	return nil
+}
+
+// scalingIn returns true while members above the desired size exist
+func scalingIn(singleGreatsql *singlev1.Single, members []*member) bool {
+	for _, m := range members {
+		if departing(singleGreatsql, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 19:42:51
+ * @file: seed.go
+ * @description: seed new members with the clone plugin
+ */
+
+// seeding holds the pods a clone is running for, keyed by namespace/name
+var seeding sync.Map
+
+// isSeeding returns true while a clone into the member is running
+func isSeeding(m *member) bool {
+	_, ok := seeding.Load(m.pod.Namespace + "/" + m.pod.Name)
+	return ok
+}
+
+// seedMember clones donor into the member in the background. The member
+// restarts with the copied data and is configured on a later reconcile.
+func seedMember(m *member, password string, donor greatsql.ReplicationSource) {
+	key := m.pod.Namespace + "/" + m.pod.Name
+	if _, running := seeding.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	log := logger.WithValues("member", key, "donor", donor.Host)
+	host := m.pod.Status.PodIP
+	go func() {
+		defer seeding.Delete(key)
+
+		db, err := greatsql.NewClient(host, greatsql.DefaultPort, greatsql.RootUser, password)
+		if err != nil {
+			log.Error(err, "Could not connect to seed member")
+			return
+		}
+		defer db.Close()
+
+		log.Info("Cloning donor into member")
+		if err := db.Clone(context.Background(), donor); err != nil {
+			log.Error(err, "Could not clone donor into member")
+			return
+		}
+		log.Info("Clone finished, member restarts with the donor data")
+	}()
+}
+
+// needsSeed returns true if the member has no data and the binlogs of the
+// donor no longer hold every transaction, replication alone can not catch up
+func needsSeed(ctx context.Context, m, donor *member) (bool, error) {
+	if m.gtid.Count() > 0 {
+		return false, nil
+	}
+	purged, err := donor.db.GTIDPurged(ctx)
+	if err != nil {
+		return false, err
+	}
+	return purged.Count() > 0, nil
+}
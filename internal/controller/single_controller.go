@@ -18,19 +18,25 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/bytedance/sonic"
 	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
 	"github.com/keington/greatsql-operator/internal/pkg/kube"
 	"github.com/keington/greatsql-operator/internal/utils"
 	"k8s.io/apimachinery/pkg/types"
@@ -39,7 +45,10 @@ import (
 // SingleReconciler reconciles a Single object
 type SingleReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// KubeClient reads the kubelet stats of the data volumes, usage is not observed without it
+	KubeClient kubernetes.Interface
 }
 
 var (
@@ -52,6 +61,16 @@ var (
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=nodes/proxy,verbs=get
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -105,6 +124,14 @@ func (r *SingleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		log.Error(err, "invalid spec, please check")
 		return ctrl.Result{}, err
 	}
+	if err := r.validateVolumeLayout(ctx, singleGreatsql); err != nil {
+		log.Error(err, "invalid spec, please check")
+		return ctrl.Result{}, err
+	}
+	if err := r.reportEphemeralStorage(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not update status")
+		return ctrl.Result{}, err
+	}
 
 	// if err := r.deleteAssociatedResources(ctx, req); err != nil {
 	// 	log.Error(err, "Could not add finalizer")
@@ -112,17 +139,47 @@ func (r *SingleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	// }
 
+	// a paused instance is left to the DBA, only its status is observed
+	paused, err := r.reconcilePaused(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not observe paused instance")
+		return ctrl.Result{}, err
+	}
+	if paused {
+		return ctrl.Result{RequeueAfter: clusterRequeueInterval}, nil
+	}
+
 	if err := finalizer.AddFinalizer(); err != nil {
 		log.Error(err, "Could not add finalizer")
 		return ctrl.Result{}, err
 	}
 
+	// the certificate secret is mounted by the pods, issue it before they are created
+	tlsReloading, err := r.reconcileTLS(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not reconcile certificates")
+		return ctrl.Result{}, err
+	}
+
+	// replicated topologies are backed by a statefulset
+	if singleGreatsql.Spec.IsCluster() {
+		return r.reconcileCluster(ctx, req, singleGreatsql)
+	}
+
+	// binlog, redo and log volumes split off the data volume, they exist before the deployment mounts them
+	for _, pvc := range kube.NewAdditionalClaims(singleGreatsql) {
+		if err := r.createIfNotExists(ctx, pvc); err != nil {
+			log.Error(err, "Could not create persistentVolumeClaim")
+			return ctrl.Result{}, err
+		}
+	}
+
 	// create deployment, persistentVolumeClaim and service
 	deployGreatsql := &appsv1.Deployment{}
 	if err := r.Client.Get(ctx, req.NamespacedName, deployGreatsql); err != nil {
 
 		// create configMap
-		configMap := kube.NewConfigMap(req.Name+"-config", req.Namespace)
+		configMap := kube.NewConfigMap(singleGreatsql, req.Name+"-config")
 		//configMap.Annotations = map[string]string{consts.ConfigMapDataHash: kubernetes.GetConfigDataHash()}
 		if err := r.Client.Create(ctx, configMap); err != nil {
 			log.Error(err, "Could not create configMap")
@@ -130,14 +187,19 @@ func (r *SingleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 		log.Info("Create configMap is successful", "Name", configMap.Name, "Namespace", configMap.Namespace)
 
-		pvc := kube.NewPersistentVolumeClaim(singleGreatsql)
-		if err := r.Client.Create(ctx, pvc); err != nil {
-			log.Error(err, "Could not create persistentVolumeClaim")
-			return ctrl.Result{}, err
+		// volumeSpec data volumes are mounted as is
+		if singleGreatsql.Spec.NeedsPersistentVolumeClaim() {
+			if err := r.ensurePersistentVolume(ctx, singleGreatsql); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			pvc := kube.NewPersistentVolumeClaim(singleGreatsql)
+			if err := r.Client.Create(ctx, pvc); err != nil {
+				log.Error(err, "Could not create persistentVolumeClaim")
+				return ctrl.Result{}, err
+			}
+			log.Info("Create persistentVolumeClaim is successful", "Name", pvc.Name, "Namespace", pvc.Namespace)
 		}
-		log.Info("Create persistentVolumeClaim is successful", "Name", pvc.Name, "Namespace", pvc.Namespace)
-
-		//kubernetes.BindPersistentVolumeAndClaim(pv, pvc)
 
 		// deployGreatsql not found, create it
 		deploy := kube.NewDeployment(singleGreatsql, req.Name+"-config")
@@ -160,7 +222,135 @@ func (r *SingleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		r.updateStatus(ctx, singleGreatsql, *service)
 	}
 
-	return r.watchResource(ctx, req, singleGreatsql)
+	// the deployment follows spec.stopped first, the steps below wait for its pod
+	if err := r.scaleDeployment(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not scale deployment")
+		return ctrl.Result{}, err
+	}
+	stopped, err := r.reconcileStopped(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not reconcile stopped instance")
+		return ctrl.Result{}, err
+	}
+	if stopped {
+		return ctrl.Result{RequeueAfter: clusterRequeueInterval}, nil
+	}
+
+	// nothing else runs against the single until its data is copied from the source
+	cloneInProgress, err := r.reconcileCloneFrom(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not clone the data source")
+		return ctrl.Result{}, err
+	}
+	if cloneInProgress {
+		return ctrl.Result{RequeueAfter: clusterRequeueInterval}, nil
+	}
+	importInProgress, err := r.reconcileImport(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not import the external server")
+		return ctrl.Result{}, err
+	}
+	if importInProgress {
+		return ctrl.Result{RequeueAfter: clusterRequeueInterval}, nil
+	}
+	standbySeeding, err := r.reconcileSingleStandby(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not replicate from the primary instance")
+		return ctrl.Result{}, err
+	}
+	if standbySeeding {
+		return ctrl.Result{RequeueAfter: clusterRequeueInterval}, nil
+	}
+	if err := r.reconcileRestoredRoot(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not check the root password of the restored data")
+		return ctrl.Result{}, err
+	}
+	initPending, err := r.reconcileInitScripts(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not run init scripts")
+		return ctrl.Result{}, err
+	}
+	if err := r.updateSingleReady(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not update ready status")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileSnapshotBackup(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile snapshot backup")
+		return ctrl.Result{}, err
+	}
+
+	// grow the data claim when the storage request was raised, a static volume can not grow
+	pending := false
+	if singleGreatsql.Spec.NeedsPersistentVolumeClaim() && singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeSource == nil {
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: kube.DataVolumeName(singleGreatsql)}, pvc); err != nil {
+			log.Error(err, "Could not get persistentVolumeClaim")
+			return ctrl.Result{}, err
+		}
+		if pending, err = r.reconcileVolumeExpansion(ctx, singleGreatsql, []corev1.PersistentVolumeClaim{*pvc}); err != nil {
+			log.Error(err, "Could not expand persistentVolumeClaim")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !pending {
+		if err := r.reconcileStorage(ctx, singleGreatsql); err != nil {
+			log.Error(err, "Could not reconcile storage usage")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.reconcileVersion(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile version")
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileSingleUpgrade(ctx, singleGreatsql); err != nil {
+		log.Error(err, "Could not reconcile upgrade")
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.watchResource(ctx, req, singleGreatsql)
+	if err != nil {
+		return result, err
+	}
+	pendingChanges, err := r.pendingSingleChanges(ctx, singleGreatsql)
+	if err != nil {
+		log.Error(err, "Could not list pending changes")
+		return ctrl.Result{}, err
+	}
+	wait, err := r.reconcilePendingRestart(ctx, singleGreatsql, pendingChanges)
+	if err != nil {
+		log.Error(err, "Could not reconcile pending restarts")
+		return ctrl.Result{}, err
+	}
+	switch {
+	case pending:
+		result.RequeueAfter = volumeExpansionInterval
+	case initPending:
+		// the scripts run once the pod is ready
+		result.RequeueAfter = clusterRequeueInterval
+	case tlsReloading:
+		// kubelet updates the mounted certificate within a minute
+		result.RequeueAfter = clusterRequeueInterval
+	case singleGreatsql.Spec.IsStandby() && !standbyPromoted(singleGreatsql):
+		// the lag is only observed on reconcile, keep looking at it
+		result.RequeueAfter = clusterRequeueInterval
+	case singleGreatsql.Spec.UpgradeOptions.VersionServiceEndpoint != "":
+		result.RequeueAfter = versionCheckInterval
+	case singleGreatsql.Spec.PodSpec.Storage != nil && singleGreatsql.Spec.PodSpec.Storage.Autoscaling != nil && singleGreatsql.Spec.PodSpec.Storage.Autoscaling.Enabled:
+		// usage is only observed on reconcile, keep looking at it
+		result.RequeueAfter = storageUsageInterval
+	}
+	// certificates are renewed long before they expire, look at them now and then
+	if singleGreatsql.Spec.TLS != nil && (result.RequeueAfter == 0 || tlsCheckInterval < result.RequeueAfter) {
+		result.RequeueAfter = tlsCheckInterval
+	}
+	// apply the held changes as soon as the maintenance window opens
+	if wait > 0 && (result.RequeueAfter == 0 || wait < result.RequeueAfter) {
+		result.RequeueAfter = wait
+	}
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -168,6 +358,7 @@ func (r *SingleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&singlev1.Single{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
 		Complete(r)
 }
 
@@ -196,15 +387,168 @@ func (r *SingleReconciler) validateSpec(spec singlev1.SingleSpec, req ctrl.Reque
 		return errors.NewBadRequest("size is required")
 	}
 
-	// validate podSpec
-	if spec.PodSpec.Storage.PersistentVolumeClaimTemplate.StorageClassName == nil {
-		log.Error(nil, "storageClassName is required")
-		return errors.NewBadRequest("storageClassName is required")
+	// a deployment shares one persistentVolumeClaim, only the cluster types scale
+	if !spec.IsCluster() && *spec.Size > 1 {
+		log.Error(nil, "size of a single instance must be 1")
+		return errors.NewBadRequest("size of a single instance must be 1, use replicaofCluster or a group cluster to scale")
+	}
+
+	// validate podSpec, the storage template only matters without a volumeSpec data volume
+	if spec.NeedsPersistentVolumeClaim() {
+		if spec.PodSpec.Storage == nil || spec.PodSpec.Storage.PersistentVolumeClaimTemplate == nil || spec.PodSpec.Storage.PersistentVolumeClaimTemplate.StorageClassName == nil {
+			log.Error(nil, "storageClassName is required")
+			return errors.NewBadRequest("storageClassName is required")
+		}
+	}
+
+	// every member needs its own data, a user claim can only be mounted by one instance
+	if spec.IsCluster() && spec.PodSpec.VolumeSpec != nil && spec.PodSpec.VolumeSpec.PersistentVolumeClaim != nil {
+		log.Error(nil, "volumeSpec.persistentVolumeClaim is not supported by cluster types")
+		return errors.NewBadRequest("volumeSpec.persistentVolumeClaim is not supported by cluster types, use storage.persistentVolumeClaimTemplate")
+	}
+
+	// a hostPath holds one datadir on one node, members sharing a node would share it
+	// and a pod scheduled to another node would start with an empty one
+	if spec.IsHostPath() {
+		if spec.IsCluster() {
+			log.Error(nil, "volumeSpec.hostPath is not supported by cluster types")
+			return errors.NewBadRequest("volumeSpec.hostPath is not supported by cluster types, use storage.persistentVolumeClaimTemplate")
+		}
+		if !spec.IsPinnedToNode() {
+			log.Error(nil, "volumeSpec.hostPath requires the pod to be pinned to a node")
+			return errors.NewBadRequest("volumeSpec.hostPath requires podSpec.nodeSelector or a required node affinity")
+		}
+	}
+
+	// the data source seeds one instance, cluster members are seeded from their primary
+	if spec.DataSource != nil && (spec.DataSource.CloneFrom != nil || spec.DataSource.External != nil) {
+		if spec.IsCluster() {
+			log.Error(nil, "dataSource is only supported by the single type")
+			return errors.NewBadRequest("dataSource is only supported by the single type")
+		}
+		if spec.DataSource.CloneFrom != nil && spec.DataSource.External != nil {
+			log.Error(nil, "dataSource.cloneFrom and dataSource.external are exclusive")
+			return errors.NewBadRequest("dataSource.cloneFrom and dataSource.external are exclusive")
+		}
+		if spec.RestoresFromDataSource() {
+			log.Error(nil, "dataSource can not be combined with a claim dataSource")
+			return errors.NewBadRequest("dataSource can not be combined with persistentVolumeClaimTemplate.dataSource")
+		}
+	}
+
+	// a standby is seeded from its primary instance, a multi-primary group has no single member to replicate into
+	if spec.IsStandby() {
+		if spec.GreatSqlType == singlev1.GreatSqlTypeMultiPrimaryGroupCluster {
+			log.Error(nil, "standby is not supported by multiPrimaryGroupCluster")
+			return errors.NewBadRequest("standby is not supported by multiPrimaryGroupCluster, use singlePrimaryGroupCluster")
+		}
+		if spec.DataSource != nil && (spec.DataSource.CloneFrom != nil || spec.DataSource.External != nil) {
+			log.Error(nil, "standby can not be combined with dataSource")
+			return errors.NewBadRequest("standby can not be combined with dataSource")
+		}
+	}
+
+	// init scripts initialize a new datadir, a standby or an import gets its data from the source
+	if len(spec.InitScripts) > 0 {
+		if spec.IsStandby() || (spec.DataSource != nil && spec.DataSource.External != nil) {
+			log.Error(nil, "initScripts can not be combined with standby or dataSource.external")
+			return errors.NewBadRequest("initScripts can not be combined with standby or dataSource.external")
+		}
+		for i, script := range spec.InitScripts {
+			if (script.ConfigMap == nil) == (script.Secret == nil) {
+				log.Error(nil, "initScripts must reference either a configMap or a secret", "index", i)
+				return errors.NewBadRequest(fmt.Sprintf("initScripts[%d]: exactly one of configMap and secret is required", i))
+			}
+		}
+	}
+
+	// the issuers need to know where the certificate comes from
+	if spec.TLS != nil {
+		if spec.TLS.Issuer == singlev1.TLSIssuerCertManager && spec.TLS.IssuerRef == nil {
+			log.Error(nil, "tls.issuerRef is required by the CertManager issuer")
+			return errors.NewBadRequest("tls.issuerRef is required by the CertManager issuer")
+		}
+		if spec.TLS.Issuer == singlev1.TLSIssuerSecret && spec.TLS.SecretName == "" {
+			log.Error(nil, "tls.secretName is required by the Secret issuer")
+			return errors.NewBadRequest("tls.secretName is required by the Secret issuer")
+		}
+		// the members verify each other against the operator CA
+		if spec.TLS.MutualTLS && (!spec.IsCluster() || spec.TLS.Issuer == singlev1.TLSIssuerCertManager || spec.TLS.Issuer == singlev1.TLSIssuerSecret) {
+			log.Error(nil, "tls.mutualTLS requires a cluster type and the Operator issuer")
+			return errors.NewBadRequest("tls.mutualTLS requires a cluster type and the Operator issuer")
+		}
+	}
+
+	// delayed replicas are asynchronous replicas, a group applies every transaction in time
+	if spec.DelayedReplica != nil {
+		if spec.GreatSqlType != singlev1.GreatSqlTypeReplicaofCluster {
+			log.Error(nil, "delayedReplica is only supported by replicaofCluster")
+			return errors.NewBadRequest("delayedReplica is only supported by replicaofCluster")
+		}
+		for _, ordinal := range spec.DelayedReplica.Ordinals {
+			if ordinal < 0 || ordinal >= spec.GetSize() {
+				log.Error(nil, "delayedReplica.ordinals must be members of the cluster", "ordinal", ordinal)
+				return errors.NewBadRequest(fmt.Sprintf("delayedReplica.ordinals: %d is not a member of the cluster", ordinal))
+			}
+		}
+	}
+
+	// a window that can not be evaluated would hold the changes forever
+	for _, window := range spec.MaintenanceWindows {
+		if _, _, err := windowOccurrence(window, time.Now()); err != nil {
+			log.Error(err, "invalid maintenanceWindows")
+			return errors.NewBadRequest(fmt.Sprintf("maintenanceWindows: %v", err))
+		}
+	}
+
+	// a static volume holds the data of one instance
+	if spec.IsCluster() && spec.PodSpec.Storage != nil && spec.PodSpec.Storage.PersistentVolumeSource != nil {
+		log.Error(nil, "storage.persistentVolumeSource is not supported by cluster types")
+		return errors.NewBadRequest("storage.persistentVolumeSource is not supported by cluster types, use a storageClass")
 	}
 
 	return nil
 }
 
+// validateVolumeLayout rejects adding or removing the binlog, redo and log volumes
+// once the instance exists. The datadir keeps its files where it was created,
+// mysqld does not start when log_bin or the redo directory point elsewhere.
+func (r *SingleReconciler) validateVolumeLayout(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	current := map[string]bool{}
+	if singleGreatsql.Spec.IsCluster() {
+		statefulSet := &appsv1.StatefulSet{}
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(singleGreatsql), statefulSet)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+			current[template.Name] = true
+		}
+	} else {
+		deployment := &appsv1.Deployment{}
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(singleGreatsql), deployment)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, volume := range deployment.Spec.Template.Spec.Volumes {
+			current[volume.Name] = true
+		}
+	}
+
+	for name, set := range kube.AdditionalVolumeLayout(singleGreatsql) {
+		if current[name] != set {
+			return errors.NewBadRequest(fmt.Sprintf("storage: volume %s can not be added or removed after creation", name))
+		}
+	}
+	return nil
+}
+
 // watchResource watches the resource
 func (r *SingleReconciler) watchResource(ctx context.Context, req ctrl.Request, singleGreatsql *singlev1.Single) (ctrl.Result, error) {
 	log := logger.WithValues("Request.Service.Namespace", req.Namespace, "Request.Service.Name", req.Name)
@@ -236,6 +580,35 @@ func (r *SingleReconciler) watchResource(ctx context.Context, req ctrl.Request,
 			log.Error(err, "Could not get old deployment")
 			return ctrl.Result{}, err
 		}
+		// a changed template restarts the pod, it waits for a maintenance window. A
+		// deployment without the hash predates the windows and takes it right away.
+		hash := oldDeployments.Annotations[consts.TemplateHash]
+		held := hash != "" && hash != newDeployments.Annotations[consts.TemplateHash] && !maintenanceWindowOpen(singleGreatsql, time.Now())
+		// a new image is only rolled out once the upgrade checks passed
+		if current, image := templateImage(singleGreatsql, oldDeployments.Spec.Template), templateImage(singleGreatsql, newDeployments.Spec.Template); !held && current != image {
+			allowed, err := r.upgradeGate(ctx, singleGreatsql, current, image)
+			if err != nil {
+				log.Error(err, "Could not check the upgrade")
+				return ctrl.Result{}, err
+			}
+			held = !allowed
+		}
+		if held {
+			newDeployments.Spec.Template = oldDeployments.Spec.Template
+			newDeployments.Annotations[consts.TemplateHash] = hash
+		}
+		// the server reads its configuration on start, it changes with the template
+		// and is updated first for the restarted pod to mount the new one
+		if newDeployments.Spec.Template.Annotations[consts.ConfigMapDataHash] == kube.ConfigDataHash(singleGreatsql) {
+			if err := r.updateConfigMap(ctx, kube.NewConfigMap(singleGreatsql, req.Name+"-config")); err != nil {
+				log.Error(err, "Could not update configMap")
+				return ctrl.Result{}, err
+			}
+		}
+		if oldDeployments.Annotations == nil {
+			oldDeployments.Annotations = map[string]string{}
+		}
+		oldDeployments.Annotations[consts.TemplateHash] = newDeployments.Annotations[consts.TemplateHash]
 		oldDeployments.Spec = newDeployments.Spec
 		if err := r.Client.Update(ctx, oldDeployments); err != nil {
 			log.Error(err, "Could not update deployment")
@@ -254,25 +627,27 @@ func (r *SingleReconciler) watchResource(ctx context.Context, req ctrl.Request,
 			return ctrl.Result{}, nil
 		}
 
-		newConfigMap := kube.NewConfigMap(req.Name+"-config", req.Namespace)
-		oldConfigMapName := req.Name + "-config"
-		oldConfigMap := &corev1.ConfigMap{}
-		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: oldConfigMapName}, oldConfigMap); err != nil {
-			log.Error(err, "Could not get old configMap")
-			return ctrl.Result{}, err
-		}
-		oldConfigMap.Data = newConfigMap.Data
-		if err := r.Client.Update(ctx, oldConfigMap); err != nil {
-			log.Error(err, "Could not update configMap")
-			return ctrl.Result{}, nil
-		}
-
 		return ctrl.Result{}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// updateSingleReady records the ready pods of the deployment, gated by the init scripts
+func (r *SingleReconciler) updateSingleReady(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	deployment := &appsv1.Deployment{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(singleGreatsql), deployment); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	before := singleGreatsql.Status.DeepCopy()
+	singleGreatsql.Status.Ready = gateReady(singleGreatsql, deployment.Status.ReadyReplicas)
+	if reflect.DeepEqual(before, &singleGreatsql.Status) {
+		return nil
+	}
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
 // updateStatus updates the status of the Single
 func (r *SingleReconciler) updateStatus(ctx context.Context, singleGreatsql *singlev1.Single, svc corev1.Service) error {
 	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
@@ -292,6 +667,8 @@ func (r *SingleReconciler) updateStatus(ctx context.Context, singleGreatsql *sin
 		Size:        singleGreatsql.Spec.GetSize(),
 		Ready:       0,
 		Age:         svc.CreationTimestamp.String(),
+		Selector:    labels.SelectorFromSet(kube.NewLabels(singleGreatsql)).String(),
+		InitScripts: newInitScriptsStatus(singleGreatsql),
 	}
 
 	if reflect.DeepEqual(singleGreatsql.Status, status) {
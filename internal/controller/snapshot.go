@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 15:47:22
+ * @file: snapshot.go
+ * @description: volumeSnapshot backups of the data volume
+ */
+
+const (
+	// snapshotCutTimeout bounds how long writes are blocked waiting for the snapshot to be cut
+	snapshotCutTimeout = 2 * time.Minute
+)
+
+// volumeSnapshotGVK is the csi volumeSnapshot, handled unstructured to not depend on the snapshotter client
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// reconcileSnapshotBackup takes the volumeSnapshot requested by the snapshot
+// backup annotation. The annotation is consumed, the outcome is recorded in the
+// SnapshotBackup condition and as an event.
+func (r *SingleReconciler) reconcileSnapshotBackup(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	name := singleGreatsql.Annotations[consts.SnapshotBackup]
+	if name == "" {
+		return nil
+	}
+	if name == "true" {
+		name = fmt.Sprintf("%s-%s", singleGreatsql.Name, time.Now().UTC().Format("20060102150405"))
+	}
+
+	delete(singleGreatsql.Annotations, consts.SnapshotBackup)
+	if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+		return err
+	}
+
+	if err := r.snapshotBackup(ctx, singleGreatsql, name); err != nil {
+		log.Error(err, "Could not take volume snapshot", "snapshot", name)
+		setCondition(singleGreatsql, singlev1.ConditionSnapshotBackup, metav1.ConditionFalse, "SnapshotFailed", err.Error())
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "SnapshotFailed", "volume snapshot %s failed: %v", name, err)
+	} else {
+		log.Info("Took volume snapshot", "snapshot", name)
+		setCondition(singleGreatsql, singlev1.ConditionSnapshotBackup, metav1.ConditionTrue, "SnapshotTaken", "volume snapshot "+name+" taken")
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeNormal, "SnapshotTaken", "volume snapshot %s taken", name)
+	}
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// snapshotBackup quiesces the primary and snapshots its data claim. Writes are
+// blocked until the snapshot is cut, not until it is ready to use.
+func (r *SingleReconciler) snapshotBackup(ctx context.Context, singleGreatsql *singlev1.Single, name string) error {
+	storage := singleGreatsql.Spec.PodSpec.Storage
+	if storage != nil && (storage.BinlogVolume != nil || storage.RedoVolume != nil) {
+		return fmt.Errorf("the binlog and redo volumes are not part of the snapshot, it would not be consistent")
+	}
+
+	pod, claimName, err := r.snapshotSource(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	release, err := db.Quiesce(ctx)
+	if err != nil {
+		return fmt.Errorf("quiesce %s: %w", pod.Name, err)
+	}
+	defer release()
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(name)
+	snapshot.SetNamespace(singleGreatsql.Namespace)
+	// no owner reference, a backup outlives the single
+	snapshot.SetLabels(kube.NewLabels(singleGreatsql))
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claimName},
+	}
+	if storage != nil && storage.VolumeSnapshotClassName != nil {
+		spec["volumeSnapshotClassName"] = *storage.VolumeSnapshotClassName
+	}
+	snapshot.Object["spec"] = spec
+	if err := r.Client.Create(ctx, snapshot); err != nil {
+		return err
+	}
+
+	// the snapshot is cut once its creation time is set
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, snapshotCutTimeout, true, func(ctx context.Context) (bool, error) {
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot); err != nil {
+			return false, err
+		}
+		if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found {
+			return false, fmt.Errorf("snapshot %s: %s", name, message)
+		}
+		_, cut, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime")
+		return cut, nil
+	})
+	if err != nil {
+		return err
+	}
+	return release()
+}
+
+// snapshotSource returns the pod holding the data to snapshot and its data claim,
+// the primary for the cluster types
+func (r *SingleReconciler) snapshotSource(ctx context.Context, singleGreatsql *singlev1.Single) (*corev1.Pod, string, error) {
+	volumeSpec := singleGreatsql.Spec.PodSpec.VolumeSpec
+	if !singleGreatsql.Spec.NeedsPersistentVolumeClaim() && (volumeSpec == nil || volumeSpec.PersistentVolumeClaim == nil) {
+		return nil, "", fmt.Errorf("the data volume is not a persistentVolumeClaim")
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return nil, "", err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.PodIP == "" || pod.DeletionTimestamp != nil || !podReady(pod) {
+			continue
+		}
+
+		switch {
+		case singleGreatsql.Spec.IsCluster():
+			if pod.Name == singleGreatsql.Status.Primary || (singleGreatsql.Spec.GreatSqlType == singlev1.GreatSqlTypeMultiPrimaryGroupCluster && singleGreatsql.Status.Primary == "") {
+				return pod, kube.DataVolumeName(singleGreatsql) + "-" + pod.Name, nil
+			}
+		case volumeSpec != nil && volumeSpec.PersistentVolumeClaim != nil:
+			return pod, volumeSpec.PersistentVolumeClaim.ClaimName, nil
+		default:
+			return pod, kube.DataVolumeName(singleGreatsql), nil
+		}
+	}
+	return nil, "", fmt.Errorf("no ready pod to snapshot")
+}
+
+// reconcileRestoredRoot checks that root of a single restored from a claim
+// dataSource takes MYSQL_ROOT_PASSWORD. The restored data keeps the accounts of
+// the snapshot source, a mismatch is reported in the Restored condition.
+func (r *SingleReconciler) reconcileRestoredRoot(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	if !singleGreatsql.Spec.RestoresFromDataSource() || meta.IsStatusConditionTrue(singleGreatsql.Status.Conditions, singlev1.ConditionRestored) {
+		return nil
+	}
+
+	pod, err := r.runningPod(ctx, singleGreatsql)
+	if err != nil || pod == nil {
+		return err
+	}
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	db, err := connectWithAny(ctx, pod.Status.PodIP, password)
+	if err != nil {
+		if !greatsql.IsAccessDenied(err) {
+			// the server is still starting
+			return nil
+		}
+		condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionRestored)
+		if condition != nil && condition.Reason == "RootPasswordMismatch" {
+			return nil
+		}
+		message := "root does not take MYSQL_ROOT_PASSWORD, the restored data keeps the root password of the snapshot source"
+		r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, "RootPasswordMismatch", message)
+		setCondition(singleGreatsql, singlev1.ConditionRestored, metav1.ConditionFalse, "RootPasswordMismatch", message)
+		return r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	db.Close()
+
+	setCondition(singleGreatsql, singlev1.ConditionRestored, metav1.ConditionTrue, "RootPasswordAccepted", "root takes MYSQL_ROOT_PASSWORD on the restored data")
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 20:15:44
+ * @file: standby.go
+ * @description: disaster recovery standby of a primary instance
+ */
+
+// replicationAccount is the account members replicate and recover with
+type replicationAccount struct {
+	user     string
+	password string
+}
+
+// replicationCredentials returns the account members replicate with. A standby
+// replicates the accounts of its primary instance, so it uses the account it
+// connects to the primary instance with instead of creating its own.
+func (r *SingleReconciler) replicationCredentials(ctx context.Context, singleGreatsql *singlev1.Single) (replicationAccount, error) {
+	if singleGreatsql.Spec.IsStandby() {
+		source, err := r.standbySource(ctx, singleGreatsql)
+		if err != nil {
+			return replicationAccount{}, err
+		}
+		return replicationAccount{user: source.User, password: source.Password}, nil
+	}
+
+	password, err := r.internalPassword(ctx, singleGreatsql, replicationPasswordKey)
+	if err != nil {
+		return replicationAccount{}, err
+	}
+	return replicationAccount{user: replicationUser, password: password}, nil
+}
+
+// memberServerID returns the server_id of a member. A standby must not reuse the
+// ids of its primary instance, replication skips events carrying its own id.
+func memberServerID(singleGreatsql *singlev1.Single, ordinal int32) int32 {
+	if singleGreatsql.Spec.IsStandby() {
+		return serverIDFor(singleGreatsql)&^0xffff | (ordinal + 1)
+	}
+	return ordinal + 1
+}
+
+// standbyPromoted returns true once the standby was detached from its primary instance
+func standbyPromoted(singleGreatsql *singlev1.Single) bool {
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionStandby)
+	return condition != nil && condition.Reason == "Promoted"
+}
+
+// seedStandby clones the primary instance into a member without data. It returns
+// true once the member holds data, the member restarts after the clone.
+func (r *SingleReconciler) seedStandby(ctx context.Context, singleGreatsql *singlev1.Single, m *member, rootPassword string) (bool, error) {
+	if !singleGreatsql.Spec.IsStandby() || standbyPromoted(singleGreatsql) || m.gtid.Count() > 0 {
+		return true, nil
+	}
+	if isSeeding(m) {
+		return false, nil
+	}
+
+	source, err := r.standbySource(ctx, singleGreatsql)
+	if err != nil {
+		return false, err
+	}
+	seedMember(m, rootPassword, source)
+	setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionFalse, "Seeding", fmt.Sprintf("cloning %s into %s", sourceAddress(source), m.pod.Name))
+	return false, nil
+}
+
+// reconcileStandby keeps leader replicating from the primary instance and read
+// only, or promotes it when the promote annotation is set. Only the leader holds
+// the channel, the other members follow it through the topology.
+func (r *SingleReconciler) reconcileStandby(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, leader *member) error {
+	if !singleGreatsql.Spec.IsStandby() || standbyPromoted(singleGreatsql) {
+		singleGreatsql.Status.Standby = nil
+		return nil
+	}
+	if singleGreatsql.Annotations[consts.PromoteStandby] != "" {
+		return r.promoteStandby(ctx, singleGreatsql, leader)
+	}
+
+	source, err := r.standbySource(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	status := &singlev1.StandbyStatus{Source: sourceAddress(source)}
+	singleGreatsql.Status.Standby = status
+
+	// a member that lost the leader role after a failover keeps its channel otherwise
+	for _, m := range members {
+		if m == leader || !m.reachable() || m.replica == nil || m.replica.SourceHost != source.Host {
+			continue
+		}
+		if err := m.db.StopReplica(ctx); err != nil {
+			return err
+		}
+		if err := m.db.ResetReplicaAll(ctx); err != nil {
+			return err
+		}
+		m.replica = nil
+	}
+
+	if !leader.reachable() {
+		status.LastError = "no member can replicate from the primary instance"
+		setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionFalse, "LeaderUnreachable", status.LastError)
+		return nil
+	}
+	status.Member = leader.pod.Name
+
+	// a newly elected primary is writable, nothing may be written outside the channel
+	if readOnly, err := leader.db.IsReadOnly(ctx); err != nil || !readOnly {
+		if err := leader.db.SetReadOnly(ctx, true); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case leader.replica == nil || leader.replica.SourceHost != source.Host || leader.replica.SSLAllowed != (source.TLS != nil):
+		if leader.replica != nil {
+			if err := leader.db.StopReplica(ctx); err != nil {
+				return err
+			}
+		}
+		if err := leader.db.ChangeReplicationSource(ctx, source); err != nil {
+			return err
+		}
+		if err := leader.db.StartReplica(ctx); err != nil {
+			return err
+		}
+		logger.Info("Replicating from primary instance", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "member", leader.pod.Name, "source", status.Source)
+	case !leader.replica.Running():
+		if err := leader.db.StartReplica(ctx); err != nil {
+			return err
+		}
+	}
+	if leader.replica, err = leader.db.ReplicaStatus(ctx); err != nil {
+		return err
+	}
+
+	status.LagSeconds = leader.replica.SecondsBehindSource
+	status.LastError = leader.replica.LastIOError
+	if status.LastError == "" {
+		status.LastError = leader.replica.LastSQLError
+	}
+	if status.LastError != "" {
+		setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionFalse, "ReplicationBroken", status.LastError)
+		return nil
+	}
+	setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionTrue, "Replicating", leader.pod.Name+" replicates from "+status.Source)
+	return nil
+}
+
+// promoteStandby detaches the leader from the primary instance and makes it
+// writable. Relay logs not applied within catchUpTimeout are given up, the
+// primary instance is usually gone when a standby is promoted.
+func (r *SingleReconciler) promoteStandby(ctx context.Context, singleGreatsql *singlev1.Single, leader *member) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	delete(singleGreatsql.Annotations, consts.PromoteStandby)
+	if err := r.Client.Update(ctx, singleGreatsql); err != nil {
+		return err
+	}
+
+	if !leader.reachable() {
+		setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionFalse, "PromoteFailed", "no member can be promoted")
+		return nil
+	}
+
+	message := "promoted " + leader.pod.Name
+	if leader.replica != nil {
+		if received, err := greatsql.ParseGTIDSet(leader.replica.RetrievedGTIDSet); err == nil {
+			applied, err := leader.db.WaitForExecutedGTIDSet(ctx, leader.gtid.Union(received), catchUpTimeout)
+			if err != nil || !applied {
+				log.Info("Promoting standby before every received transaction was applied", "member", leader.pod.Name)
+				message += ", some received transactions were not applied"
+			}
+		}
+	}
+	if err := promote(ctx, leader); err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionFalse, "PromoteFailed", err.Error())
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "PromoteFailed", "could not promote standby: %v", err)
+		return nil
+	}
+
+	log.Info("Promoted standby", "member", leader.pod.Name)
+	singleGreatsql.Status.Standby = nil
+	setCondition(singleGreatsql, singlev1.ConditionStandby, metav1.ConditionTrue, "Promoted", message)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "StandbyPromoted", message)
+	return nil
+}
+
+// reconcileSingleStandby runs the standby of the single type. It returns true
+// while the single is being seeded.
+func (r *SingleReconciler) reconcileSingleStandby(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	if !singleGreatsql.Spec.IsStandby() || standbyPromoted(singleGreatsql) {
+		return false, nil
+	}
+
+	pod, err := r.runningPod(ctx, singleGreatsql)
+	if err != nil || pod == nil {
+		return true, err
+	}
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	source, err := r.standbySource(ctx, singleGreatsql)
+	if err != nil {
+		return true, err
+	}
+	// root has the password of the primary once the clone is done
+	db, err := r.connectCloned(ctx, singleGreatsql, singlev1.ConditionStandby, pod.Status.PodIP, password, source)
+	if err != nil || db == nil {
+		return true, err
+	}
+	db.Close()
+
+	m := &member{pod: pod, state: singlev1.MemberStateUnreachable}
+	if err := m.connect(ctx, password, memberServerID(singleGreatsql, 0)); err != nil {
+		return true, err
+	}
+	defer m.db.Close()
+
+	seeded, err := r.seedStandby(ctx, singleGreatsql, m, password)
+	if err == nil && seeded {
+		err = r.reconcileStandby(ctx, singleGreatsql, nil, m)
+	}
+	if statusErr := r.Client.Status().Update(ctx, singleGreatsql); statusErr != nil {
+		return true, statusErr
+	}
+	return !seeded, err
+}
+
+// standbySource returns the primary instance and the account to replicate with
+func (r *SingleReconciler) standbySource(ctx context.Context, singleGreatsql *singlev1.Single) (greatsql.ReplicationSource, error) {
+	standby := singleGreatsql.Spec.Standby
+	source, err := r.sourceWithCredentials(ctx, singleGreatsql, standby.Host, standby.Port, standby.CredentialsSecret)
+	if err != nil {
+		return source, err
+	}
+	// the primary instance is another cluster, the channel is encrypted without client certificate
+	if singleGreatsql.Spec.TLS != nil {
+		source.TLS = &greatsql.TLSFiles{}
+	}
+	return source, nil
+}
+
+// sourceAddress returns host:port of a replication source
+func sourceAddress(source greatsql.ReplicationSource) string {
+	return net.JoinHostPort(source.Host, strconv.Itoa(int(source.Port)))
+}
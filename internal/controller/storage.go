@@ -0,0 +1,354 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 14:08:51
+ * @file: storage.go
+ * @description: data volume usage and storage autoscaling
+ */
+
+const (
+	// storageUsageInterval is the requeue interval of a single watching its storage usage
+	storageUsageInterval = time.Minute
+)
+
+// statsSummary is the part of the kubelet stats summary reporting pod volumes
+type statsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Volumes []struct {
+			Name          string  `json:"name"`
+			UsedBytes     *uint64 `json:"usedBytes"`
+			CapacityBytes *uint64 `json:"capacityBytes"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// reconcileStorage reports the data volume usage and grows the storage request by
+// a step once a member crosses the threshold. At the ceiling the binary logs every
+// member already applied are purged as a last resort.
+func (r *SingleReconciler) reconcileStorage(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	if r.KubeClient == nil {
+		return nil
+	}
+
+	usage, err := r.observeVolumeUsage(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(usage, singleGreatsql.Status.Volumes) {
+		singleGreatsql.Status.Volumes = usage
+		if err := r.Client.Status().Update(ctx, singleGreatsql); err != nil {
+			return err
+		}
+	}
+
+	storage := singleGreatsql.Spec.PodSpec.Storage
+	if storage == nil || storage.Autoscaling == nil || !storage.Autoscaling.Enabled ||
+		!singleGreatsql.Spec.NeedsPersistentVolumeClaim() || storage.PersistentVolumeSource != nil {
+		return nil
+	}
+	autoscaling := storage.Autoscaling
+
+	var fullest *singlev1.VolumeUsage
+	for i := range usage {
+		if fullest == nil || usage[i].UsedPercent > fullest.UsedPercent {
+			fullest = &usage[i]
+		}
+	}
+	if fullest == nil || fullest.UsedPercent < autoscaling.ThresholdPercent {
+		return r.setStorageCeiling(ctx, singleGreatsql, false, "every data volume is below the threshold")
+	}
+
+	// wait for the previous step to be applied before taking the next one
+	if condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionVolumeExpansion); condition != nil && condition.Reason == "Expanding" {
+		return nil
+	}
+
+	current := kube.StorageRequest(singleGreatsql)
+	if autoscaling.MaxSize == nil || current.Cmp(*autoscaling.MaxSize) < 0 {
+		if autoscaling.Step.IsZero() {
+			return nil
+		}
+		next := current.DeepCopy()
+		next.Add(autoscaling.Step)
+		if autoscaling.MaxSize != nil && next.Cmp(*autoscaling.MaxSize) > 0 {
+			next = autoscaling.MaxSize.DeepCopy()
+		}
+
+		patch := client.MergeFrom(singleGreatsql.DeepCopy())
+		template := singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeClaimTemplate
+		if template.Resources.Requests == nil {
+			template.Resources.Requests = corev1.ResourceList{}
+		}
+		template.Resources.Requests[corev1.ResourceStorage] = next
+		if err := r.Client.Patch(ctx, singleGreatsql, patch); err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("%s uses %d%% of its data volume, storage grows from %s to %s", fullest.Pod, fullest.UsedPercent, current.String(), next.String())
+		log.Info("Autoscaling storage", "pod", fullest.Pod, "from", current.String(), "to", next.String())
+		r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "StorageAutoscaled", message)
+		return nil
+	}
+
+	message := fmt.Sprintf("%s uses %d%% of its data volume and storage is at its maximum size %s", fullest.Pod, fullest.UsedPercent, autoscaling.MaxSize.String())
+	if err := r.setStorageCeiling(ctx, singleGreatsql, true, message); err != nil {
+		return err
+	}
+	if !autoscaling.PurgeBinlogs {
+		return nil
+	}
+
+	purgedTo, err := r.purgeAppliedBinlogs(ctx, singleGreatsql, fullest.Pod)
+	if err != nil {
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "BinlogPurgeFailed", "could not purge binary logs of %s: %v", fullest.Pod, err)
+		return err
+	}
+	if purgedTo != "" {
+		log.Info("Purged binary logs", "pod", fullest.Pod, "to", purgedTo)
+		r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "BinlogsPurged", "purged binary logs of %s before %s", fullest.Pod, purgedTo)
+	}
+	return nil
+}
+
+// setStorageCeiling records whether a data volume is full at the maximum size,
+// the event is only emitted when it gets there
+func (r *SingleReconciler) setStorageCeiling(ctx context.Context, singleGreatsql *singlev1.Single, reached bool, message string) error {
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionStorageCeiling)
+	if !reached {
+		if condition == nil || condition.Status == metav1.ConditionFalse {
+			return nil
+		}
+		setCondition(singleGreatsql, singlev1.ConditionStorageCeiling, metav1.ConditionFalse, "BelowThreshold", message)
+		return r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	if condition != nil && condition.Status == metav1.ConditionTrue {
+		return nil
+	}
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, "StorageCeilingReached", message)
+	setCondition(singleGreatsql, singlev1.ConditionStorageCeiling, metav1.ConditionTrue, "CeilingReached", message)
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// observeVolumeUsage reads the data volume usage of every pod from the kubelet
+// stats summary of its node
+func (r *SingleReconciler) observeVolumeUsage(ctx context.Context, singleGreatsql *singlev1.Single) ([]singlev1.VolumeUsage, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return nil, err
+	}
+
+	summaries := map[string]*statsSummary{}
+	var usage []singlev1.VolumeUsage
+	for _, pod := range pods.Items {
+		node := pod.Spec.NodeName
+		if node == "" {
+			continue
+		}
+		if _, ok := summaries[node]; !ok {
+			summary, err := r.nodeStatsSummary(ctx, node)
+			if err != nil {
+				log.Info("Could not read kubelet stats", "node", node, "error", err.Error())
+			}
+			summaries[node] = summary
+		}
+		if summaries[node] == nil {
+			continue
+		}
+
+		for _, podStats := range summaries[node].Pods {
+			if podStats.PodRef.Name != pod.Name || podStats.PodRef.Namespace != pod.Namespace {
+				continue
+			}
+			for _, volume := range podStats.Volumes {
+				if volume.Name != kube.DataVolumeName(singleGreatsql) || volume.UsedBytes == nil || volume.CapacityBytes == nil || *volume.CapacityBytes == 0 {
+					continue
+				}
+				usage = append(usage, singlev1.VolumeUsage{
+					Pod:           pod.Name,
+					UsedBytes:     int64(*volume.UsedBytes),
+					CapacityBytes: int64(*volume.CapacityBytes),
+					UsedPercent:   int32(*volume.UsedBytes * 100 / *volume.CapacityBytes),
+				})
+			}
+		}
+	}
+	return usage, nil
+}
+
+// nodeStatsSummary fetches the kubelet stats summary through the node proxy
+func (r *SingleReconciler) nodeStatsSummary(ctx context.Context, node string) (*statsSummary, error) {
+	data, err := r.KubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(node).SubResource("proxy").Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &statsSummary{}
+	if err := sonic.Unmarshal(data, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// purgeAppliedBinlogs purges the binary logs of the pod holding only transactions
+// every other pod and every standby replicating from the single already applied.
+// Every consumer has to be reachable, an unreachable one may still need them, and
+// a connected replica the operator does not know stops the purge. It returns the
+// oldest file kept.
+func (r *SingleReconciler) purgeAppliedBinlogs(ctx context.Context, singleGreatsql *singlev1.Single, podName string) (string, error) {
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return "", err
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return "", err
+	}
+
+	var target *greatsql.Client
+	var applied []greatsql.GTIDSet
+	known := map[string]bool{}
+	consume := func(name, host, password string) error {
+		db, err := greatsql.NewClient(host, greatsql.DefaultPort, greatsql.RootUser, password)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		uuid, err := db.ServerUUID(ctx)
+		if err != nil {
+			return fmt.Errorf("%s may still need the binary logs: %w", name, err)
+		}
+		gtid, err := db.GTIDExecuted(ctx)
+		if err != nil {
+			return fmt.Errorf("%s may still need the binary logs: %w", name, err)
+		}
+		known[uuid] = true
+		applied = append(applied, gtid)
+		return nil
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.PodIP == "" {
+			return "", fmt.Errorf("%s is not running and may still need the binary logs", pod.Name)
+		}
+		if pod.Name != podName {
+			if err := consume(pod.Name, pod.Status.PodIP, password); err != nil {
+				return "", err
+			}
+			continue
+		}
+		db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+		uuid, err := db.ServerUUID(ctx)
+		if err != nil {
+			return "", err
+		}
+		known[uuid] = true
+		target = db
+	}
+	if target == nil {
+		return "", fmt.Errorf("pod %s not found", podName)
+	}
+
+	standbys, err := r.standbysOf(ctx, singleGreatsql)
+	if err != nil {
+		return "", err
+	}
+	for _, standby := range standbys {
+		name := standby.Namespace + "/" + standby.Name
+		if standby.Status.Standby == nil || standby.Status.Standby.Member == "" {
+			return "", fmt.Errorf("standby %s is not replicating and may still need the binary logs", name)
+		}
+		pod := &corev1.Pod{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: standby.Namespace, Name: standby.Status.Standby.Member}, pod); err != nil {
+			return "", err
+		}
+		standbyPassword, err := r.rootPassword(ctx, standby)
+		if err != nil {
+			return "", err
+		}
+		if err := consume("standby "+name, pod.Status.PodIP, standbyPassword); err != nil {
+			return "", err
+		}
+	}
+
+	// external replicas and binlog archivers report no position the operator can read
+	replicas, err := target.ConnectedReplicas(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, replica := range replicas {
+		if !known[replica.UUID] {
+			return "", fmt.Errorf("replica %s (server_id %s) is connected and its position is unknown", replica.Host, replica.ServerID)
+		}
+	}
+	if len(applied) == 0 {
+		return "", fmt.Errorf("no replica reports the transactions it applied, the binary logs may still be needed")
+	}
+
+	return target.PurgeAppliedBinaryLogs(ctx, applied)
+}
+
+// standbysOf returns the standbys that were not promoted and replicate from one
+// of the services of the single
+func (r *SingleReconciler) standbysOf(ctx context.Context, singleGreatsql *singlev1.Single) ([]*singlev1.Single, error) {
+	hosts := map[string]bool{}
+	for _, service := range []string{singleGreatsql.Name, singleGreatsql.Name + "-read"} {
+		hosts[service+"."+singleGreatsql.Namespace] = true
+		hosts[service+"."+singleGreatsql.Namespace+".svc"] = true
+		hosts[service+"."+singleGreatsql.Namespace+".svc.cluster.local"] = true
+	}
+	if host, _, found := strings.Cut(singleGreatsql.Status.AccessPoint, ":"); found && host != "" {
+		hosts[host] = true
+	}
+
+	singles := &singlev1.SingleList{}
+	if err := r.Client.List(ctx, singles); err != nil {
+		return nil, err
+	}
+	var standbys []*singlev1.Single
+	for i := range singles.Items {
+		s := &singles.Items[i]
+		if !s.Spec.IsStandby() || standbyPromoted(s) {
+			continue
+		}
+		if hosts[s.Spec.Standby.Host] || (s.Namespace == singleGreatsql.Namespace && (s.Spec.Standby.Host == singleGreatsql.Name || s.Spec.Standby.Host == singleGreatsql.Name+"-read")) {
+			standbys = append(standbys, s)
+		}
+	}
+	return standbys, nil
+}
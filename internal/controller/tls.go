@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/certs"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-24 11:10:32
+ * @file: tls.go
+ * @description: certificates of encrypted client and replication connections
+ */
+
+const (
+	// caValidity is how long the CA of a single is valid, it is renewed after two thirds
+	caValidity = 10 * 365 * 24 * time.Hour
+	// certificateValidity is how long an operator issued certificate is valid
+	certificateValidity = 365 * 24 * time.Hour
+	// tlsCheckInterval is how often the certificates are checked for renewal
+	tlsCheckInterval = time.Hour
+)
+
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch
+
+// reconcileTLS issues the server certificate of spec.tls and reloads it on the
+// members once kubelet updated the mounted secret. It returns true while a
+// member serves a previous certificate.
+func (r *SingleReconciler) reconcileTLS(ctx context.Context, singleGreatsql *singlev1.Single) (bool, error) {
+	spec := singleGreatsql.Spec.TLS
+	if spec == nil {
+		return false, nil
+	}
+
+	switch spec.Issuer {
+	case singlev1.TLSIssuerCertManager:
+		if err := r.ensureCertManagerCertificate(ctx, singleGreatsql); err != nil {
+			return false, err
+		}
+	case singlev1.TLSIssuerSecret:
+	default:
+		ca, bundle, err := r.ensureCA(ctx, singleGreatsql)
+		if err != nil {
+			return false, err
+		}
+		if err := r.ensureOperatorCertificate(ctx, singleGreatsql, ca, bundle); err != nil {
+			return false, err
+		}
+		if kube.MutualTLS(singleGreatsql) {
+			if err := r.ensureMemberCertificates(ctx, singleGreatsql, ca, bundle); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.TLSSecretName(singleGreatsql)}, secret)
+	if errors.IsNotFound(err) {
+		setCondition(singleGreatsql, singlev1.ConditionTLS, metav1.ConditionFalse, "WaitingForCertificate", fmt.Sprintf("secret %s does not exist yet", kube.TLSSecretName(singleGreatsql)))
+		return false, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+	if err != nil {
+		return false, err
+	}
+	cert, err := certs.ParseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionTLS, metav1.ConditionFalse, "InvalidCertificate", fmt.Sprintf("secret %s: %v", secret.Name, err))
+		return false, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+
+	stale, restart, err := r.reloadTLS(ctx, singleGreatsql, cert)
+	if err != nil {
+		return false, err
+	}
+
+	singleGreatsql.Status.TLS = &singlev1.TLSStatus{NotAfter: &metav1.Time{Time: cert.NotAfter}}
+	switch {
+	case stale > 0:
+		setCondition(singleGreatsql, singlev1.ConditionTLS, metav1.ConditionFalse, "Reloading", fmt.Sprintf("%d members wait for kubelet to update the certificate", stale))
+	case restart > 0:
+		setCondition(singleGreatsql, singlev1.ConditionTLS, metav1.ConditionFalse, "PendingRestart", fmt.Sprintf("%d members load the certificate on their next restart", restart))
+	default:
+		setCondition(singleGreatsql, singlev1.ConditionTLS, metav1.ConditionTrue, "Serving", fmt.Sprintf("the certificate is valid until %s", cert.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	return stale > 0, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// reloadTLS loads the certificate on every ready member that serves another one.
+// It returns the members still serving another certificate after the reload,
+// kubelet did not update their files yet, and the members that were started
+// before spec.tls was set.
+func (r *SingleReconciler) reloadTLS(ctx context.Context, singleGreatsql *singlev1.Single, cert *x509.Certificate) (int, int, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(singleGreatsql.Namespace), client.MatchingLabels(kube.NewLabels(singleGreatsql))); err != nil {
+		return 0, 0, err
+	}
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stale, restart := 0, 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.PodIP == "" || pod.DeletionTimestamp != nil || !podReady(pod) {
+			continue
+		}
+		reloaded, pending, err := reloadMemberTLS(ctx, pod, password, cert)
+		if err != nil {
+			logger.Error(err, "Could not reload certificate", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "pod", pod.Name)
+			stale++
+			continue
+		}
+		switch {
+		case pending:
+			restart++
+		case !reloaded:
+			stale++
+		}
+	}
+	return stale, restart, nil
+}
+
+// reloadMemberTLS reloads the certificate of a member unless it serves cert
+// already. It returns whether the member serves cert and whether it has to be
+// restarted to load certificates from the mounted secret at all.
+func reloadMemberTLS(ctx context.Context, pod *corev1.Pod, password string, cert *x509.Certificate) (bool, bool, error) {
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return false, false, err
+	}
+	defer db.Close()
+
+	path, err := db.GetVariable(ctx, "ssl_cert")
+	if err != nil {
+		return false, false, err
+	}
+	if path != kube.TLSCertPath {
+		return false, true, nil
+	}
+
+	notAfter, err := db.TLSNotAfter(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	if notAfter.Unix() == cert.NotAfter.Unix() {
+		return true, false, nil
+	}
+	if err := db.ReloadTLS(ctx); err != nil {
+		return false, false, err
+	}
+	if notAfter, err = db.TLSNotAfter(ctx); err != nil {
+		return false, false, err
+	}
+	if notAfter.Unix() != cert.NotAfter.Unix() {
+		return false, false, nil
+	}
+	logger.Info("Reloaded certificate", "pod", pod.Name, "notAfter", cert.NotAfter)
+	return true, false, nil
+}
+
+// ensureOperatorCertificate signs the server certificate with the CA of the
+// single and renews it after two thirds of its validity
+func (r *SingleReconciler) ensureOperatorCertificate(ctx context.Context, singleGreatsql *singlev1.Single, ca *certs.KeyPair, bundle []byte) error {
+	dnsNames := kube.TLSDNSNames(singleGreatsql)
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.TLSSecretName(singleGreatsql)}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	found := err == nil
+	if found {
+		cert, err := certs.ParseCertificate(secret.Data[corev1.TLSCertKey])
+		if err == nil && !certs.NeedsRenewal(cert, ca.Cert, dnsNames, time.Now()) && bytes.Equal(secret.Data[kube.CACertKey], bundle) {
+			return nil
+		}
+	}
+
+	pair, err := certs.NewCertificate(ca, singleGreatsql.Name, dnsNames, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, certificateValidity)
+	if err != nil {
+		return err
+	}
+	key, err := pair.KeyPEM()
+	if err != nil {
+		return err
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:       pair.CertPEM(),
+		corev1.TLSPrivateKeyKey: key,
+		kube.CACertKey:          bundle,
+	}
+
+	logger.Info("Issuing server certificate", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "notAfter", pair.Cert.NotAfter)
+	r.Recorder.Eventf(singleGreatsql, corev1.EventTypeNormal, "CertificateIssued", "issued a server certificate valid until %s", pair.Cert.NotAfter.UTC().Format(time.RFC3339))
+	if !found {
+		return r.Client.Create(ctx, kube.NewCertificateSecret(singleGreatsql, kube.TLSSecretName(singleGreatsql), corev1.SecretTypeTLS, data))
+	}
+	secret.Data = data
+	return r.Client.Update(ctx, secret)
+}
+
+// ensureMemberCertificates signs a client certificate for every member, it
+// names the member so a certificate can not be taken for another member. The
+// certificates of members removed by a scale in are kept, they may come back.
+func (r *SingleReconciler) ensureMemberCertificates(ctx context.Context, singleGreatsql *singlev1.Single, ca *certs.KeyPair, bundle []byte) error {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.MemberTLSSecretName(singleGreatsql)}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	found := err == nil
+
+	data := map[string][]byte{}
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	changed := !bytes.Equal(data[kube.CACertKey], bundle)
+	data[kube.CACertKey] = bundle
+	for i := int32(0); i < singleGreatsql.Spec.GetSize(); i++ {
+		podName := fmt.Sprintf("%s-%d", singleGreatsql.Name, i)
+		dnsNames := []string{kube.PodFQDN(singleGreatsql, podName)}
+		if cert, err := certs.ParseCertificate(data[kube.MemberCertKey(podName)]); err == nil && !certs.NeedsRenewal(cert, ca.Cert, dnsNames, time.Now()) {
+			continue
+		}
+
+		pair, err := certs.NewCertificate(ca, podName, dnsNames, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, certificateValidity)
+		if err != nil {
+			return err
+		}
+		key, err := pair.KeyPEM()
+		if err != nil {
+			return err
+		}
+		data[kube.MemberCertKey(podName)] = pair.CertPEM()
+		data[kube.MemberKeyKey(podName)] = key
+		changed = true
+		logger.Info("Issuing member certificate", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "member", podName, "notAfter", pair.Cert.NotAfter)
+	}
+	if !changed {
+		return nil
+	}
+
+	if !found {
+		return r.Client.Create(ctx, kube.NewCertificateSecret(singleGreatsql, kube.MemberTLSSecretName(singleGreatsql), corev1.SecretTypeOpaque, data))
+	}
+	secret.Data = data
+	return r.Client.Update(ctx, secret)
+}
+
+// memberTLS returns the files a member connects to the other members with, nil
+// without tls. Without mutual tls the connection is encrypted only.
+func memberTLS(singleGreatsql *singlev1.Single, podName string) *greatsql.TLSFiles {
+	if singleGreatsql.Spec.TLS == nil {
+		return nil
+	}
+	if !kube.MutualTLS(singleGreatsql) {
+		return &greatsql.TLSFiles{}
+	}
+	return &greatsql.TLSFiles{
+		CA:   kube.MemberTLSDir + "/" + kube.CACertKey,
+		Cert: kube.MemberTLSDir + "/" + kube.MemberCertKey(podName),
+		Key:  kube.MemberTLSDir + "/" + kube.MemberKeyKey(podName),
+	}
+}
+
+// ensureCA returns the CA of the single and the bundle clients trust, it holds
+// the previous CA as well until it expires so a renewed CA does not break clients
+func (r *SingleReconciler) ensureCA(ctx context.Context, singleGreatsql *singlev1.Single) (*certs.KeyPair, []byte, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: kube.CASecretName(singleGreatsql)}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, nil, err
+	}
+	found := err == nil
+
+	var previous *x509.Certificate
+	if found {
+		ca, err := certs.ParseKeyPair(secret.Data[kube.CACertKey], secret.Data[kube.CAKeyKey])
+		if err != nil {
+			return nil, nil, fmt.Errorf("secret %s: %w", secret.Name, err)
+		}
+		if !certs.NeedsRenewal(ca.Cert, nil, nil, time.Now()) {
+			return ca, secret.Data[kube.CACertKey], nil
+		}
+		previous = ca.Cert
+	}
+
+	ca, err := certs.NewCA(singleGreatsql.Namespace+"/"+singleGreatsql.Name+" CA", caValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := ca.KeyPEM()
+	if err != nil {
+		return nil, nil, err
+	}
+	bundle := ca.CertPEM()
+	if previous != nil && time.Now().Before(previous.NotAfter) {
+		bundle = append(bundle, (&certs.KeyPair{Cert: previous}).CertPEM()...)
+	}
+	data := map[string][]byte{kube.CACertKey: bundle, kube.CAKeyKey: key}
+
+	r.Recorder.Eventf(singleGreatsql, corev1.EventTypeNormal, "CAIssued", "issued a CA valid until %s", ca.Cert.NotAfter.UTC().Format(time.RFC3339))
+	if !found {
+		return ca, bundle, r.Client.Create(ctx, kube.NewCertificateSecret(singleGreatsql, kube.CASecretName(singleGreatsql), corev1.SecretTypeOpaque, data))
+	}
+	secret.Data = data
+	return ca, bundle, r.Client.Update(ctx, secret)
+}
+
+// ensureCertManagerCertificate keeps the cert-manager Certificate in line with
+// the spec, cert-manager issues and renews the secret
+func (r *SingleReconciler) ensureCertManagerCertificate(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	desired := kube.NewCertificate(singleGreatsql)
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(kube.CertificateGVK)
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(desired), certificate)
+	if errors.IsNotFound(err) {
+		return r.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(certificate.Object["spec"], desired.Object["spec"]) {
+		return nil
+	}
+	certificate.Object["spec"] = desired.Object["spec"]
+	return r.Client.Update(ctx, certificate)
+}
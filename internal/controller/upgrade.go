@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/versionservice"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-21 11:40:52
+ * @file: upgrade.go
+ * @description: upgrade checks and member by member rollout
+ */
+
+const (
+	// defaultMemberTimeout is used when upgradeOptions.memberTimeoutSeconds is not set
+	defaultMemberTimeout = 10 * time.Minute
+)
+
+// upgradeGate runs the checks before image is rolled out: the data dictionary
+// must not be downgraded, the pre-upgrade checks must pass and, if asked, the
+// primary is snapshotted. It returns false while the image must not be rolled out.
+func (r *SingleReconciler) upgradeGate(ctx context.Context, singleGreatsql *singlev1.Single, current, image string) (bool, error) {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	message := fmt.Sprintf("upgrading from %s to %s", current, image)
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionUpgrade)
+	if condition != nil && condition.Reason == "Upgrading" && condition.Message == message {
+		return true, nil
+	}
+
+	pod, err := r.primaryPod(ctx, singleGreatsql)
+	if err != nil {
+		return false, err
+	}
+	if pod == nil {
+		// nothing runs that could be checked, the new image may be what fixes it
+		log.Info("No running server to check before the upgrade", "image", image)
+		setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionFalse, "Upgrading", message)
+		return true, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+
+	reason, problem, err := r.checkUpgrade(ctx, singleGreatsql, pod)
+	if err != nil {
+		return false, err
+	}
+	if problem == "" && singleGreatsql.Spec.UpgradeOptions.BackupBeforeUpgrade {
+		name := fmt.Sprintf("%s-pre-upgrade-%s", singleGreatsql.Name, time.Now().UTC().Format("20060102150405"))
+		if err := r.snapshotBackup(ctx, singleGreatsql, name); err != nil {
+			reason, problem = "BackupFailed", fmt.Sprintf("pre-upgrade snapshot %s failed: %v", name, err)
+		} else {
+			r.Recorder.Eventf(singleGreatsql, corev1.EventTypeNormal, "SnapshotTaken", "pre-upgrade volume snapshot %s taken", name)
+		}
+	}
+
+	if problem != "" {
+		log.Info("Upgrade blocked", "image", image, "reason", problem)
+		if condition == nil || condition.Message != problem {
+			r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, reason, problem)
+		}
+		setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionFalse, reason, problem)
+		return false, r.Client.Status().Update(ctx, singleGreatsql)
+	}
+
+	log.Info("Upgrade checks passed", "from", current, "to", image)
+	setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionFalse, "Upgrading", message)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Upgrading", message)
+	return true, r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// checkUpgrade runs the downgrade and pre-upgrade checks against the server in
+// pod. It returns the condition reason and the problem, empty if there is none.
+func (r *SingleReconciler) checkUpgrade(ctx context.Context, singleGreatsql *singlev1.Single, pod *corev1.Pod) (string, string, error) {
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return "", "", err
+	}
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return "", "", err
+	}
+	defer db.Close()
+
+	running, err := db.GetVariable(ctx, "version")
+	if err != nil {
+		return "", "", err
+	}
+	// the data dictionary is upgraded on start and can not be downgraded again
+	if target := targetVersion(singleGreatsql); target != "" && versionservice.CompareRelease(target, running) < 0 {
+		return "DowngradeBlocked", fmt.Sprintf("downgrading from %s to %s is not supported by the data dictionary", running, target), nil
+	}
+
+	problems, err := db.PreUpgradeCheck(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if len(problems) > 0 {
+		return "PreCheckFailed", strings.Join(problems, "; "), nil
+	}
+	return "", "", nil
+}
+
+// rollMembers restarts the members not on the update revision of the statefulset
+// one at a time, replicas first. The primary is handed to an updated member with
+// switchover before it is restarted. A restarted member that is not healthy within
+// the member timeout halts the rollout until the template changes again.
+func (r *SingleReconciler) rollMembers(ctx context.Context, singleGreatsql *singlev1.Single, members []*member, primary *member, healthy func(*member) bool, switchover func(*member) error) error {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(singleGreatsql), statefulSet); err != nil {
+		return err
+	}
+	revision := statefulSet.Status.UpdateRevision
+	if revision == "" {
+		return nil
+	}
+
+	var outdated, updated []*member
+	for _, m := range members {
+		switch {
+		case departing(singleGreatsql, m) || catchUpHeld(singleGreatsql, m):
+			// a delayed replica held by a catch-up would resume its delay after a restart
+		case m.pod.Labels[appsv1.StatefulSetRevisionLabel] == revision:
+			updated = append(updated, m)
+		default:
+			outdated = append(outdated, m)
+		}
+	}
+
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionUpgrade)
+	if len(outdated) == 0 {
+		if condition != nil && (condition.Reason == "Upgrading" || condition.Reason == "Restarting") {
+			setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionTrue, "Upgraded", "every member runs "+singleGreatsql.Spec.PodSpec.Image)
+			r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Upgraded", "every member runs "+singleGreatsql.Spec.PodSpec.Image)
+		}
+		return nil
+	}
+	if condition != nil && condition.Reason == "UpgradeFailed" && strings.Contains(condition.Message, revision) {
+		return nil
+	}
+
+	// wait for the last restarted member before touching the next one
+	timeout := memberTimeout(singleGreatsql)
+	for _, m := range updated {
+		if healthy(m) {
+			continue
+		}
+		if time.Since(m.pod.CreationTimestamp.Time) > timeout {
+			message := fmt.Sprintf("%s is not healthy on revision %s after %s, the rollout is halted. Revert the change to roll it back", m.pod.Name, revision, timeout)
+			setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionFalse, "UpgradeFailed", message)
+			r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, "UpgradeFailed", message)
+		}
+		return nil
+	}
+
+	// restarts wait for a maintenance window
+	if !maintenanceWindowOpen(singleGreatsql, time.Now()) {
+		return nil
+	}
+
+	// a member that is down anyway goes first, then the highest ordinals
+	sort.Slice(outdated, func(i, j int) bool {
+		if healthy(outdated[i]) != healthy(outdated[j]) {
+			return !healthy(outdated[i])
+		}
+		return outdated[i].ordinal > outdated[j].ordinal
+	})
+	if m := outdated[0]; m != primary && !healthy(m) {
+		return r.restartMember(ctx, singleGreatsql, m, revision)
+	}
+	// otherwise only with every member healthy, a restart must not cost redundancy
+	for _, m := range outdated {
+		if !healthy(m) {
+			return nil
+		}
+	}
+	for _, m := range outdated {
+		if m != primary {
+			return r.restartMember(ctx, singleGreatsql, m, revision)
+		}
+	}
+
+	// only the primary is left, hand its role to an updated member first
+	var candidate *member
+	for _, m := range updated {
+		if !delayed(singleGreatsql, m) && healthy(m) {
+			candidate = m
+			break
+		}
+	}
+	if candidate == nil {
+		return r.restartMember(ctx, singleGreatsql, primary, revision)
+	}
+	if err := switchover(candidate); err != nil {
+		setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionFalse, "Restarting", fmt.Sprintf("could not switch over to %s before restarting %s: %v", candidate.pod.Name, primary.pod.Name, err))
+		return err
+	}
+	logger.Info("Switched over before restarting the primary", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "old", primary.pod.Name, "new", candidate.pod.Name)
+	return nil
+}
+
+// restartMember deletes the pod of a member, the statefulset recreates it on the update revision
+func (r *SingleReconciler) restartMember(ctx context.Context, singleGreatsql *singlev1.Single, m *member, revision string) error {
+	logger.Info("Restarting member for the new revision", "Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name, "member", m.pod.Name, "revision", revision)
+	if err := r.Client.Delete(ctx, m.pod); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionUpgrade)
+	if condition == nil || condition.Reason != "Upgrading" {
+		setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionFalse, "Restarting", fmt.Sprintf("restarting %s for revision %s", m.pod.Name, revision))
+	}
+	return nil
+}
+
+// reconcileSingleUpgrade records the end of an upgrade of the single type, the
+// deployment replaces the pod by itself
+func (r *SingleReconciler) reconcileSingleUpgrade(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	condition := meta.FindStatusCondition(singleGreatsql.Status.Conditions, singlev1.ConditionUpgrade)
+	if condition == nil || condition.Reason != "Upgrading" {
+		return nil
+	}
+	pod, err := r.readyPod(ctx, singleGreatsql)
+	if err != nil || pod == nil || containerImage(singleGreatsql, pod) != singleGreatsql.Spec.PodSpec.Image {
+		return err
+	}
+	setCondition(singleGreatsql, singlev1.ConditionUpgrade, metav1.ConditionTrue, "Upgraded", "running "+singleGreatsql.Spec.PodSpec.Image)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeNormal, "Upgraded", "running "+singleGreatsql.Spec.PodSpec.Image)
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
+
+// primaryPod returns the pod of the primary, any ready pod when there is no
+// single primary, nil if none is ready
+func (r *SingleReconciler) primaryPod(ctx context.Context, singleGreatsql *singlev1.Single) (*corev1.Pod, error) {
+	if singleGreatsql.Spec.IsCluster() && singleGreatsql.Status.Primary != "" {
+		pod := &corev1.Pod{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: singleGreatsql.Namespace, Name: singleGreatsql.Status.Primary}, pod)
+		if err == nil && podReady(pod) && pod.DeletionTimestamp == nil {
+			return pod, nil
+		}
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+	}
+	return r.readyPod(ctx, singleGreatsql)
+}
+
+// targetVersion returns the version spec asks for, podSpec.version or the image
+// tag, empty if neither is a version
+func targetVersion(singleGreatsql *singlev1.Single) string {
+	if version := singleGreatsql.Spec.PodSpec.Version; version != "" {
+		return version
+	}
+	image := singleGreatsql.Spec.PodSpec.Image
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	tag := image[i+1:]
+	if tag == "" || tag[0] < '0' || tag[0] > '9' {
+		return ""
+	}
+	return tag
+}
+
+// memberTimeout returns how long a restarted member may take to become healthy
+func memberTimeout(singleGreatsql *singlev1.Single) time.Duration {
+	if seconds := singleGreatsql.Spec.UpgradeOptions.MemberTimeoutSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultMemberTimeout
+}
+
+// templateImage returns the image of the greatsql container of a pod template
+func templateImage(singleGreatsql *singlev1.Single, template corev1.PodTemplateSpec) string {
+	return containerImage(singleGreatsql, &corev1.Pod{Spec: template.Spec})
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+	"github.com/keington/greatsql-operator/internal/pkg/versionservice"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-21 10:02:48
+ * @file: version.go
+ * @description: running version and upgradeOptions
+ */
+
+const (
+	// versionCheckInterval is how often the version service is asked
+	versionCheckInterval = time.Hour
+)
+
+// reconcileVersion records the running version and asks the version service
+// for the available ones. Unless apply is disabled the image is moved to the
+// version it resolves to, recommended and latest never move it backwards.
+func (r *SingleReconciler) reconcileVersion(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	status := &singlev1.VersionStatus{}
+	if singleGreatsql.Status.Version != nil {
+		status = singleGreatsql.Status.Version.DeepCopy()
+	}
+
+	pod, err := r.readyPod(ctx, singleGreatsql)
+	if err != nil {
+		return err
+	}
+	if pod != nil {
+		if running, err := r.serverVersion(ctx, singleGreatsql, pod); err != nil {
+			log.Info("Could not read the running version", "pod", pod.Name, "error", err.Error())
+		} else {
+			status.Running = running
+			status.Image = containerImage(singleGreatsql, pod)
+		}
+	}
+
+	options := singleGreatsql.Spec.UpgradeOptions
+	var target *versionservice.Version
+	if options.VersionServiceEndpoint != "" && (status.CheckedAt == nil || time.Since(status.CheckedAt.Time) >= versionCheckInterval) {
+		versions, err := versionservice.NewClient(options.VersionServiceEndpoint).Versions(ctx, status.Running)
+		if err != nil {
+			log.Error(err, "Could not query the version service")
+			r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "VersionServiceFailed", "could not query the version service: %v", err)
+		} else {
+			now := metav1.Now()
+			status.CheckedAt = &now
+			status.Latest, status.Recommended = "", ""
+			if v, found := versionservice.Latest(versions); found {
+				status.Latest = v.Version
+			}
+			if v, found := versionservice.Recommended(versions); found {
+				status.Recommended = v.Version
+			}
+
+			if options.Apply != "" && options.Apply != singlev1.UpgradeApplyDisabled {
+				v, err := versionservice.Resolve(versions, options.Apply)
+				switch {
+				case err != nil:
+					r.Recorder.Eventf(singleGreatsql, corev1.EventTypeWarning, "VersionUnavailable", "upgradeOptions.apply: %v", err)
+				case (options.Apply == singlev1.UpgradeApplyRecommended || options.Apply == singlev1.UpgradeApplyLatest) &&
+					status.Running != "" && versionservice.Compare(v.Version, status.Running) < 0:
+					log.Info("Version service offers an older version than the running one", "running", status.Running, "offered", v.Version)
+				default:
+					target = &v
+				}
+			}
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(singleGreatsql.Status.Version, status) {
+		singleGreatsql.Status.Version = status
+		if err := r.Client.Status().Update(ctx, singleGreatsql); err != nil {
+			return err
+		}
+	}
+
+	if target == nil || target.Image == singleGreatsql.Spec.PodSpec.Image {
+		return nil
+	}
+	patch := client.MergeFrom(singleGreatsql.DeepCopy())
+	previous := singleGreatsql.Spec.PodSpec.Image
+	singleGreatsql.Spec.PodSpec.Image = target.Image
+	singleGreatsql.Spec.PodSpec.Version = target.Version
+	if err := r.Client.Patch(ctx, singleGreatsql, patch); err != nil {
+		return err
+	}
+	log.Info("Applied version from the version service", "apply", options.Apply, "from", previous, "to", target.Image)
+	r.Recorder.Eventf(singleGreatsql, corev1.EventTypeNormal, "VersionApplied", "upgradeOptions.apply %s moved the image from %s to %s", options.Apply, previous, target.Image)
+	return nil
+}
+
+// serverVersion returns @@version of the server running in pod
+func (r *SingleReconciler) serverVersion(ctx context.Context, singleGreatsql *singlev1.Single, pod *corev1.Pod) (string, error) {
+	password, err := r.rootPassword(ctx, singleGreatsql)
+	if err != nil {
+		return "", err
+	}
+	db, err := greatsql.NewClient(pod.Status.PodIP, greatsql.DefaultPort, greatsql.RootUser, password)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	return db.GetVariable(ctx, "version")
+}
+
+// containerImage returns the image of the greatsql container of pod
+func containerImage(singleGreatsql *singlev1.Single, pod *corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == singleGreatsql.Name {
+			return container.Image
+		}
+	}
+	return ""
+}
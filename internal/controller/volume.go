@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/kube"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 10:02:47
+ * @file: volume.go
+ * @description: statically provisioned data volumes
+ */
+
+// ensurePersistentVolume creates the persistentVolume of storage.persistentVolumeSource.
+// A retained volume released by a previous claim of the same name is made available
+// again so a recreated single binds its old data.
+func (r *SingleReconciler) ensurePersistentVolume(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	log := logger.WithValues("Request.Service.Namespace", singleGreatsql.Namespace, "Request.Service.Name", singleGreatsql.Name)
+
+	if singleGreatsql.Spec.PodSpec.Storage == nil || singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeSource == nil {
+		return nil
+	}
+
+	pv := kube.NewPersistentVolume(singleGreatsql)
+	existing := &corev1.PersistentVolume{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(pv), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if err := r.Client.Create(ctx, pv); err != nil {
+			log.Error(err, "Could not create persistentVolume")
+			return err
+		}
+		log.Info("Create persistentVolume is successful", "Name", pv.Name)
+		return nil
+	}
+
+	claimRef := existing.Spec.ClaimRef
+	if existing.Status.Phase != corev1.VolumeReleased || claimRef == nil ||
+		claimRef.Namespace != pv.Spec.ClaimRef.Namespace || claimRef.Name != pv.Spec.ClaimRef.Name {
+		return nil
+	}
+
+	// the uid still points at the deleted claim, drop it so the new claim can bind
+	patch := client.MergeFrom(existing.DeepCopy())
+	existing.Spec.ClaimRef = pv.Spec.ClaimRef
+	if err := r.Client.Patch(ctx, existing, patch); err != nil {
+		return err
+	}
+	log.Info("Released persistentVolume made available to the new claim", "Name", existing.Name)
+	return nil
+}
+
+// reportEphemeralStorage warns once that an emptyDir data volume loses the data
+// with the pod, the condition stays while it is used
+func (r *SingleReconciler) reportEphemeralStorage(ctx context.Context, singleGreatsql *singlev1.Single) error {
+	if !singleGreatsql.Spec.IsEphemeral() {
+		if meta.RemoveStatusCondition(&singleGreatsql.Status.Conditions, singlev1.ConditionEphemeralStorage) {
+			return r.Client.Status().Update(ctx, singleGreatsql)
+		}
+		return nil
+	}
+	if meta.IsStatusConditionTrue(singleGreatsql.Status.Conditions, singlev1.ConditionEphemeralStorage) {
+		return nil
+	}
+
+	message := "volumeSpec.emptyDir is used as data volume, the data is lost when the pod restarts"
+	setCondition(singleGreatsql, singlev1.ConditionEphemeralStorage, metav1.ConditionTrue, "EmptyDir", message)
+	r.Recorder.Event(singleGreatsql, corev1.EventTypeWarning, "EphemeralStorage", message)
+	return r.Client.Status().Update(ctx, singleGreatsql)
+}
@@ -0,0 +1,152 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"slices"
+	"time"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-24 09:26:41
+ * @file: certs.go
+ * @description: certificate authority and certificates of the servers
+ */
+
+// KeyPair is a certificate and its private key
+type KeyPair struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+// NewCA returns a self-signed certificate authority
+func NewCA(commonName string, validity time.Duration) (*KeyPair, error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	return sign(template, nil)
+}
+
+// NewCertificate returns a certificate signed by ca for the given dns names and usages
+func NewCertificate(ca *KeyPair, commonName string, dnsNames []string, usages []x509.ExtKeyUsage, validity time.Duration) (*KeyPair, error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.DNSNames = dnsNames
+	template.ExtKeyUsage = usages
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	return sign(template, ca)
+}
+
+// NeedsRenewal returns true once two thirds of the validity passed, or if the
+// certificate does not cover the dns names or was not signed by ca
+func NeedsRenewal(cert *x509.Certificate, ca *x509.Certificate, dnsNames []string, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	if now.After(cert.NotBefore.Add(lifetime * 2 / 3)) {
+		return true
+	}
+	for _, name := range dnsNames {
+		if !slices.Contains(cert.DNSNames, name) {
+			return true
+		}
+	}
+	return ca != nil && cert.CheckSignatureFrom(ca) != nil
+}
+
+// CertPEM returns the PEM encoded certificate
+func (k *KeyPair) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: k.Cert.Raw})
+}
+
+// KeyPEM returns the PEM encoded PKCS #8 private key
+func (k *KeyPair) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParseCertificate returns the first certificate of a PEM bundle
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// ParseKeyPair returns the key pair of a PEM certificate and PKCS #8 key
+func ParseKeyPair(certPEM, keyPEM []byte) (*KeyPair, error) {
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an ecdsa key")
+	}
+	return &KeyPair{Cert: cert, Key: ecKey}, nil
+}
+
+// newTemplate returns a template valid from a few minutes ago, clocks of the
+// servers may lag behind the operator
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"greatsql-operator"}},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// sign generates the key of template and signs it with ca, itself without ca
+func sign(template *x509.Certificate, ca *KeyPair) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	parent, signer := template, key
+	if ca != nil {
+		parent, signer = ca.Cert, ca.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{Cert: cert, Key: key}, nil
+}
@@ -0,0 +1,54 @@
+package certs
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestCertificate(t *testing.T) {
+	ca, err := NewCA("ca", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"single.greatsql.svc"}
+	server, err := NewCertificate(ca, "single", names, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, 3*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPEM, err := server.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseKeyPair(append(server.CertPEM(), ca.CertPEM()...), keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Cert.Equal(server.Cert) || !parsed.Key.Equal(server.Key) {
+		t.Errorf("ParseKeyPair() did not return the first certificate and its key")
+	}
+
+	now := time.Now()
+	other, err := NewCA("other", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		ca    *x509.Certificate
+		names []string
+		now   time.Time
+		renew bool
+	}{
+		{"valid", ca.Cert, names, now, false},
+		{"two thirds passed", ca.Cert, names, now.Add(2 * time.Hour), true},
+		{"new dns name", ca.Cert, append(names, "single-read.greatsql.svc"), now, true},
+		{"other ca", other.Cert, names, now, true},
+	}
+	for _, c := range cases {
+		if got := NeedsRenewal(server.Cert, c.ca, c.names, c.now); got != c.renew {
+			t.Errorf("%s: NeedsRenewal() = %v, want %v", c.name, got, c.renew)
+		}
+	}
+}
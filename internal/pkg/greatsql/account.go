@@ -0,0 +1,149 @@
+package greatsql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 16:55:40
+ * @file: account.go
+ * @description: account operation
+ */
+
+// UserExists returns true if the account user@'%' exists
+func (c *Client) UserExists(ctx context.Context, user string) (bool, error) {
+	var exists int
+	if err := c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM mysql.user WHERE user = ? AND host = '%'", user).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists > 0, nil
+}
+
+// DropUser removes the account user@'%' if it exists
+func (c *Client) DropUser(ctx context.Context, user string) error {
+	return c.Exec(ctx, "DROP USER IF EXISTS ?@'%'", user)
+}
+
+// SetRootPassword sets the password of the local and remote root accounts
+func (c *Client) SetRootPassword(ctx context.Context, password string) error {
+	return c.Exec(ctx, "ALTER USER IF EXISTS 'root'@'%' IDENTIFIED BY ?, 'root'@'localhost' IDENTIFIED BY ?", password, password)
+}
+
+// AccountOptions describes the authentication and resource limits of an account
+type AccountOptions struct {
+	Password              string
+	Plugin                string // server default if empty
+	MaxQueriesPerHour     int32
+	MaxUpdatesPerHour     int32
+	MaxConnectionsPerHour int32
+	MaxUserConnections    int32
+	// Owner is stored in the user attributes, it tells the accounts of the
+	// operator apart from the ones created by hand
+	Owner string
+}
+
+// Grant describes privileges on a database or a table, * for every one
+type Grant struct {
+	Privileges      []string
+	Database        string
+	Table           string
+	WithGrantOption bool
+}
+
+// EnsureAccount creates the account user@host or brings an existing one to the options
+func (c *Client) EnsureAccount(ctx context.Context, user, host string, options AccountOptions) error {
+	identified := "IDENTIFIED BY ?"
+	if options.Plugin != "" {
+		identified = "IDENTIFIED WITH " + options.Plugin + " BY ?"
+	}
+	limits := fmt.Sprintf(" WITH MAX_QUERIES_PER_HOUR %d MAX_UPDATES_PER_HOUR %d MAX_CONNECTIONS_PER_HOUR %d MAX_USER_CONNECTIONS %d",
+		options.MaxQueriesPerHour, options.MaxUpdatesPerHour, options.MaxConnectionsPerHour, options.MaxUserConnections)
+
+	args := []any{user, host, options.Password}
+	if options.Owner != "" {
+		attribute, err := json.Marshal(map[string]string{ownerAttribute: options.Owner})
+		if err != nil {
+			return err
+		}
+		limits += " ATTRIBUTE ?"
+		args = append(args, string(attribute))
+	}
+
+	if err := c.Exec(ctx, "CREATE USER IF NOT EXISTS ?@? "+identified+limits, args...); err != nil {
+		return err
+	}
+	return c.Exec(ctx, "ALTER USER ?@? "+identified+limits, args...)
+}
+
+// ownerAttribute is the key of the user attributes holding the owner of an account
+const ownerAttribute = "owner"
+
+// AccountOwner returns the owner EnsureAccount recorded on the account user@host,
+// empty for an account created without one. exists is false without the account.
+func (c *Client) AccountOwner(ctx context.Context, user, host string) (owner string, exists bool, err error) {
+	var attribute sql.NullString
+	err = c.db.QueryRowContext(ctx, "SELECT ATTRIBUTE FROM INFORMATION_SCHEMA.USER_ATTRIBUTES WHERE USER = ? AND HOST = ?", user, host).Scan(&attribute)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil || !attribute.Valid {
+		return "", err == nil, err
+	}
+	attributes := map[string]any{}
+	if err := json.Unmarshal([]byte(attribute.String), &attributes); err != nil {
+		return "", true, err
+	}
+	owner, _ = attributes[ownerAttribute].(string)
+	return owner, true, nil
+}
+
+// DropAccount removes the account user@host if it exists
+func (c *Client) DropAccount(ctx context.Context, user, host string) error {
+	return c.Exec(ctx, "DROP USER IF EXISTS ?@?", user, host)
+}
+
+// GrantPrivileges gives the privileges of grant to user@host
+func (c *Client) GrantPrivileges(ctx context.Context, user, host string, grant Grant) error {
+	query := fmt.Sprintf("GRANT %s ON %s TO ?@?", strings.Join(grant.Privileges, ", "), grantTarget(grant))
+	if grant.WithGrantOption {
+		query += " WITH GRANT OPTION"
+	}
+	return c.Exec(ctx, query, user, host)
+}
+
+// RevokePrivileges takes the privileges of grant from user@host, privileges it
+// does not hold are ignored
+func (c *Client) RevokePrivileges(ctx context.Context, user, host string, grant Grant) error {
+	privileges := strings.Join(grant.Privileges, ", ")
+	if grant.WithGrantOption {
+		privileges += ", GRANT OPTION"
+	}
+	return c.Exec(ctx, fmt.Sprintf("REVOKE IF EXISTS %s ON %s FROM ?@? IGNORE UNKNOWN USER", privileges, grantTarget(grant)), user, host)
+}
+
+// grantTarget returns the quoted database.table of a grant
+func grantTarget(grant Grant) string {
+	table := grant.Table
+	if table == "" {
+		table = "*"
+	}
+	// _ and % are wildcards in the database of a grant, the name is meant as is
+	database := grant.Database
+	if database != "*" {
+		database = strings.NewReplacer("_", `\_`, "%", `\%`).Replace(database)
+	}
+	return quoteName(database) + "." + quoteName(table)
+}
+
+// quoteName quotes an identifier, * stays the wildcard
+func quoteName(name string) string {
+	if name == "*" {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,21 @@
+package greatsql
+
+import "testing"
+
+func TestGrantTarget(t *testing.T) {
+	cases := []struct {
+		grant Grant
+		want  string
+	}{
+		{Grant{Database: "*", Table: "*"}, "*.*"},
+		{Grant{Database: "app"}, "`app`.*"},
+		{Grant{Database: "app", Table: "orders"}, "`app`.`orders`"},
+		{Grant{Database: "a`b", Table: "*"}, "`a``b`.*"},
+		{Grant{Database: "my_app%", Table: "*"}, "`my\\_app\\%`.*"},
+	}
+	for _, c := range cases {
+		if got := grantTarget(c.grant); got != c.want {
+			t.Errorf("grantTarget(%+v) = %q, want %q", c.grant, got, c.want)
+		}
+	}
+}
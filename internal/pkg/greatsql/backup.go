@@ -0,0 +1,39 @@
+package greatsql
+
+import (
+	"context"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 15:32:10
+ * @file: backup.go
+ * @description: backup locks
+ */
+
+// Quiesce blocks DDL and writes so the data files can be copied or snapshotted
+// consistently. The locks are held by a dedicated connection until release is
+// called, the client must not be used in between.
+func (c *Client) Quiesce(ctx context.Context) (release func() error, err error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range []string{"LOCK INSTANCE FOR BACKUP", "FLUSH TABLES WITH READ LOCK"} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return func() error {
+		// closing the connection drops the locks even if unlocking fails
+		defer conn.Close()
+		if _, err := conn.ExecContext(context.Background(), "UNLOCK TABLES"); err != nil {
+			return err
+		}
+		_, err := conn.ExecContext(context.Background(), "UNLOCK INSTANCE")
+		return err
+	}, nil
+}
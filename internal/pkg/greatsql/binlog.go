@@ -0,0 +1,94 @@
+package greatsql
+
+import (
+	"context"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 13:41:26
+ * @file: binlog.go
+ * @description: binary log operation
+ */
+
+// BinaryLogs returns the binary log files of the server, oldest first
+func (c *Client) BinaryLogs(ctx context.Context) ([]string, error) {
+	rows, err := c.queryRows(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(rows))
+	for _, row := range rows {
+		files = append(files, row["Log_name"])
+	}
+	return files, nil
+}
+
+// ConnectedReplica is a row of SHOW REPLICAS, a replica currently reading the binary logs
+type ConnectedReplica struct {
+	ServerID string
+	Host     string // report_host of the replica, empty if it does not report one
+	UUID     string
+}
+
+// ConnectedReplicas returns the replicas connected to the server
+func (c *Client) ConnectedReplicas(ctx context.Context) ([]ConnectedReplica, error) {
+	rows, err := c.queryRows(ctx, "SHOW REPLICAS")
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]ConnectedReplica, 0, len(rows))
+	for _, row := range rows {
+		replicas = append(replicas, ConnectedReplica{ServerID: row["Server_Id"], Host: row["Host"], UUID: row["Replica_UUID"]})
+	}
+	return replicas, nil
+}
+
+// PreviousGTIDs returns the transactions written before the binary log file
+func (c *Client) PreviousGTIDs(ctx context.Context, file string) (GTIDSet, error) {
+	rows, err := c.queryRows(ctx, "SHOW BINLOG EVENTS IN ? LIMIT 2", file)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row["Event_type"] == "Previous_gtids" {
+			return ParseGTIDSet(row["Info"])
+		}
+	}
+	return GTIDSet{}, nil
+}
+
+// PurgeAppliedBinaryLogs purges the binary logs holding only transactions every
+// set in applied contains. It returns the oldest file kept, empty if none was
+// purged. Nothing is purged without any set, nobody reported what it applied.
+func (c *Client) PurgeAppliedBinaryLogs(ctx context.Context, applied []GTIDSet) (string, error) {
+	if len(applied) == 0 {
+		return "", nil
+	}
+	files, err := c.BinaryLogs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// every file before a file whose previous transactions were applied everywhere can go
+	for i := len(files) - 1; i > 0; i-- {
+		previous, err := c.PreviousGTIDs(ctx, files[i])
+		if err != nil {
+			return "", err
+		}
+
+		purgeable := true
+		for _, set := range applied {
+			if !set.Contains(previous) {
+				purgeable = false
+				break
+			}
+		}
+		if purgeable {
+			return files[i], c.Exec(ctx, "PURGE BINARY LOGS TO ?", files[i])
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,145 @@
+package greatsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 10:31:05
+ * @file: client.go
+ * @description: greatsql sql client
+ */
+
+const (
+	// DefaultPort is the port mysqld listens on inside the pod
+	DefaultPort int32 = 3306
+	// RootUser is the administrative account the operator connects with
+	RootUser = "root"
+
+	// errAccessDenied is ER_ACCESS_DENIED_ERROR
+	errAccessDenied = 1045
+
+	dialTimeout = 5 * time.Second
+	ioTimeout   = 30 * time.Second
+)
+
+// Client is a connection to a single GreatSql server
+type Client struct {
+	db  *sql.DB
+	cfg *mysql.Config
+}
+
+// NewClient opens a connection to the server at host:port
+func NewClient(host string, port int32, user, password string) (*Client, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = user
+	cfg.Passwd = password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	cfg.Timeout = dialTimeout
+	cfg.ReadTimeout = ioTimeout
+	cfg.WriteTimeout = ioTimeout
+	// statements like CHANGE REPLICATION SOURCE cannot be prepared, so the
+	// arguments are escaped by the driver instead
+	cfg.InterpolateParams = true
+	// encrypted if the server offers it, require_secure_transport rejects plain
+	// connections. The certificate is not verified, the server is reached by ip.
+	cfg.TLSConfig = "preferred"
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	return &Client{db: db, cfg: cfg}, nil
+}
+
+// IsAccessDenied reports whether the server rejected the account or its password
+func IsAccessDenied(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errAccessDenied
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.db.Close()
+}
+
+// Ping runs a trivial query to check the server answers
+func (c *Client) Ping(ctx context.Context) error {
+	var one int
+	return c.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// Exec executes a single statement
+func (c *Client) Exec(ctx context.Context, query string, args ...any) error {
+	if _, err := c.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec %q: %w", query, err)
+	}
+	return nil
+}
+
+// GetVariable returns the global value of a system variable
+func (c *Client) GetVariable(ctx context.Context, name string) (string, error) {
+	var value sql.NullString
+	if err := c.db.QueryRowContext(ctx, "SELECT @@GLOBAL."+name).Scan(&value); err != nil {
+		return "", err
+	}
+	return value.String, nil
+}
+
+// installPlugin installs a server plugin unless it is already loaded
+func (c *Client) installPlugin(ctx context.Context, name, soname string) error {
+	var plugins int
+	if err := c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.plugins WHERE PLUGIN_NAME = ?", name).Scan(&plugins); err != nil {
+		return err
+	}
+	if plugins > 0 {
+		return nil
+	}
+	return c.Exec(ctx, "INSTALL PLUGIN "+name+" SONAME '"+soname+"'")
+}
+
+// queryRows returns every row of the query as a column name to value map
+func (c *Client) queryRows(ctx context.Context, query string, args ...any) ([]map[string]string, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			row[column] = values[i].String
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
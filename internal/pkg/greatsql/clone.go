@@ -0,0 +1,73 @@
+package greatsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 19:20:03
+ * @file: clone.go
+ * @description: clone plugin operation
+ */
+
+// errRestartUnsupervised is returned by CLONE INSTANCE once the data is copied
+// but mysqld is not run by a supervisor, the container restart takes its place
+const errRestartUnsupervised = 3707
+
+// InstallClonePlugin installs the clone plugin, both donor and recipient need it
+func (c *Client) InstallClonePlugin(ctx context.Context) error {
+	return c.installPlugin(ctx, "clone", "mysql_clone.so")
+}
+
+// Clone replaces the data of this server with a copy of donor and restarts the
+// server. It runs on its own connection without read timeout as copying a large
+// datadir takes a long time.
+func (c *Client) Clone(ctx context.Context, donor ReplicationSource) error {
+	if err := c.InstallClonePlugin(ctx); err != nil {
+		return err
+	}
+	donorAddr := net.JoinHostPort(donor.Host, strconv.Itoa(int(donor.Port)))
+	if err := c.Exec(ctx, "SET GLOBAL clone_valid_donor_list = ?", donorAddr); err != nil {
+		return err
+	}
+	// the donor account may require a client certificate
+	files := TLSFiles{}
+	if donor.TLS != nil {
+		files = *donor.TLS
+	}
+	if err := c.Exec(ctx, "SET GLOBAL clone_ssl_ca = ?, GLOBAL clone_ssl_cert = ?, GLOBAL clone_ssl_key = ?", files.CA, files.Cert, files.Key); err != nil {
+		return err
+	}
+
+	cfg := c.cfg.Clone()
+	cfg.ReadTimeout = 0
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "CLONE INSTANCE FROM ?@?:? IDENTIFIED BY ?", donor.User, donor.Host, donor.Port, donor.Password)
+	var mysqlErr *mysql.MySQLError
+	if err == nil || (errors.As(err, &mysqlErr) && mysqlErr.Number == errRestartUnsupervised) {
+		return nil
+	}
+	return fmt.Errorf("clone from %s: %w", donorAddr, err)
+}
+
+// GTIDPurged returns the transactions no longer available in the binlogs
+func (c *Client) GTIDPurged(ctx context.Context) (GTIDSet, error) {
+	value, err := c.GetVariable(ctx, "gtid_purged")
+	if err != nil {
+		return nil, err
+	}
+	return ParseGTIDSet(value)
+}
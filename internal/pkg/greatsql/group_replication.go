@@ -0,0 +1,162 @@
+package greatsql
+
+import (
+	"context"
+	"strings"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 15:02:44
+ * @file: group_replication.go
+ * @description: group replication (mgr) operation
+ */
+
+const (
+	// GroupReplicationPort is the port of the group communication engine
+	GroupReplicationPort int32 = 33061
+
+	recoveryChannel = "group_replication_recovery"
+)
+
+// GroupMember is a row of performance_schema.replication_group_members
+type GroupMember struct {
+	ID    string
+	Host  string
+	Port  string
+	State string
+	Role  string
+}
+
+// GroupConfig defines the group a member belongs to
+type GroupConfig struct {
+	GroupName     string
+	LocalAddress  string
+	Seeds         []string
+	SinglePrimary bool
+	// credentials of the distributed recovery channel
+	RecoveryUser     string
+	RecoveryPassword string
+	// TLS encrypts group communication and distributed recovery, with a client
+	// certificate the members verify each other against the CA
+	TLS *TLSFiles
+}
+
+// ServerUUID returns the server_uuid, the member id inside the group
+func (c *Client) ServerUUID(ctx context.Context) (string, error) {
+	return c.GetVariable(ctx, "server_uuid")
+}
+
+// GroupMembers returns the members of the group as seen by this server
+func (c *Client) GroupMembers(ctx context.Context) ([]GroupMember, error) {
+	rows, err := c.queryRows(ctx, "SELECT MEMBER_ID, MEMBER_HOST, MEMBER_PORT, MEMBER_STATE, MEMBER_ROLE FROM performance_schema.replication_group_members")
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]GroupMember, 0, len(rows))
+	for _, row := range rows {
+		if row["MEMBER_ID"] == "" {
+			// the plugin reports one empty row while it is stopped
+			continue
+		}
+		members = append(members, GroupMember{
+			ID:    row["MEMBER_ID"],
+			Host:  row["MEMBER_HOST"],
+			Port:  row["MEMBER_PORT"],
+			State: row["MEMBER_STATE"],
+			Role:  row["MEMBER_ROLE"],
+		})
+	}
+	return members, nil
+}
+
+// ConfigureGroupReplication installs the plugins and persists the group settings,
+// the member does not start group replication on boot, the operator starts it
+func (c *Client) ConfigureGroupReplication(ctx context.Context, cfg GroupConfig) error {
+	if err := c.installPlugin(ctx, "group_replication", "group_replication.so"); err != nil {
+		return err
+	}
+	// distributed recovery falls back to cloning a donor when binlogs were purged
+	if err := c.InstallClonePlugin(ctx); err != nil {
+		return err
+	}
+
+	singlePrimary, everywhereChecks := "ON", "OFF"
+	if !cfg.SinglePrimary {
+		singlePrimary, everywhereChecks = "OFF", "ON"
+	}
+
+	// group communication uses the server certificate, recovery the files of the member
+	sslMode, recoverySSL, verifyServer := "DISABLED", "OFF", "OFF"
+	files := TLSFiles{}
+	if cfg.TLS != nil {
+		sslMode, recoverySSL, files = "REQUIRED", "ON", *cfg.TLS
+	}
+	if cfg.TLS.Mutual() {
+		sslMode, verifyServer = "VERIFY_CA", "ON"
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{"SET PERSIST group_replication_group_name = ?", []any{cfg.GroupName}},
+		{"SET PERSIST group_replication_local_address = ?", []any{cfg.LocalAddress}},
+		{"SET PERSIST group_replication_group_seeds = ?", []any{strings.Join(cfg.Seeds, ",")}},
+		{"SET PERSIST group_replication_start_on_boot = OFF", nil},
+		{"SET PERSIST group_replication_single_primary_mode = " + singlePrimary, nil},
+		{"SET PERSIST group_replication_enforce_update_everywhere_checks = " + everywhereChecks, nil},
+		{"SET PERSIST group_replication_ssl_mode = " + sslMode, nil},
+		{"SET PERSIST group_replication_recovery_use_ssl = " + recoverySSL, nil},
+		{"SET PERSIST group_replication_recovery_ssl_ca = ?", []any{files.CA}},
+		{"SET PERSIST group_replication_recovery_ssl_cert = ?", []any{files.Cert}},
+		{"SET PERSIST group_replication_recovery_ssl_key = ?", []any{files.Key}},
+		{"SET PERSIST group_replication_recovery_ssl_verify_server_cert = " + verifyServer, nil},
+		{"CHANGE REPLICATION SOURCE TO SOURCE_USER = ?, SOURCE_PASSWORD = ?, GET_SOURCE_PUBLIC_KEY = 1 FOR CHANNEL '" + recoveryChannel + "'", []any{cfg.RecoveryUser, cfg.RecoveryPassword}},
+	}
+	for _, stmt := range statements {
+		if err := c.Exec(ctx, stmt.query, stmt.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartGroupReplication joins the group, bootstrap creates the group from this member
+func (c *Client) StartGroupReplication(ctx context.Context, bootstrap bool) error {
+	if !bootstrap {
+		return c.Exec(ctx, "START GROUP_REPLICATION")
+	}
+
+	if err := c.Exec(ctx, "SET GLOBAL group_replication_bootstrap_group = ON"); err != nil {
+		return err
+	}
+	startErr := c.Exec(ctx, "START GROUP_REPLICATION")
+	// never leave bootstrap on, a later restart would create a second group
+	if err := c.Exec(ctx, "SET GLOBAL group_replication_bootstrap_group = OFF"); err != nil && startErr == nil {
+		return err
+	}
+	return startErr
+}
+
+// ReceivedGTIDSet returns the transactions the group applier channel received,
+// they are applied on start so they count when comparing members
+func (c *Client) ReceivedGTIDSet(ctx context.Context) (GTIDSet, error) {
+	rows, err := c.queryRows(ctx, "SHOW REPLICA STATUS FOR CHANNEL 'group_replication_applier'")
+	if err != nil || len(rows) == 0 {
+		return GTIDSet{}, err
+	}
+	return ParseGTIDSet(rows[0]["Retrieved_Gtid_Set"])
+}
+
+// StopGroupReplication leaves the group
+func (c *Client) StopGroupReplication(ctx context.Context) error {
+	return c.Exec(ctx, "STOP GROUP_REPLICATION")
+}
+
+// SetAsPrimary asks the group to elect the member with the given server_uuid
+func (c *Client) SetAsPrimary(ctx context.Context, memberID string) error {
+	var result string
+	return c.db.QueryRowContext(ctx, "SELECT group_replication_set_as_primary(?)", memberID).Scan(&result)
+}
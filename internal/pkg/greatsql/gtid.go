@@ -0,0 +1,185 @@
+package greatsql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 10:12:37
+ * @file: gtid.go
+ * @description: gtid set parse and compare
+ */
+
+// interval is a closed range of transaction ids [start, end]
+type interval struct {
+	start int64
+	end   int64
+}
+
+// GTIDSet is the parsed form of gtid_executed, keyed by source uuid
+type GTIDSet map[string][]interval
+
+// ParseGTIDSet parses a gtid set such as "uuid:1-10:12,uuid2:1-3"
+func ParseGTIDSet(s string) (GTIDSet, error) {
+	set := GTIDSet{}
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\n", "")
+	if s == "" {
+		return set, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid gtid set %q", part)
+		}
+		uuid := strings.ToLower(fields[0])
+		for _, rng := range fields[1:] {
+			bounds := strings.SplitN(rng, "-", 2)
+			start, err := strconv.ParseInt(bounds[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gtid interval %q: %w", rng, err)
+			}
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid gtid interval %q: %w", rng, err)
+				}
+			}
+			set[uuid] = append(set[uuid], interval{start: start, end: end})
+		}
+	}
+
+	for uuid := range set {
+		set[uuid] = normalize(set[uuid])
+	}
+	return set, nil
+}
+
+// Contains returns true if every transaction of other is also in s
+func (s GTIDSet) Contains(other GTIDSet) bool {
+	for uuid, intervals := range other {
+		for _, in := range intervals {
+			if !covered(s[uuid], in) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Equal returns true if both sets hold the same transactions
+func (s GTIDSet) Equal(other GTIDSet) bool {
+	return s.Contains(other) && other.Contains(s)
+}
+
+// Union returns a new set holding the transactions of both sets
+func (s GTIDSet) Union(other GTIDSet) GTIDSet {
+	union := GTIDSet{}
+	for _, set := range []GTIDSet{s, other} {
+		for uuid, intervals := range set {
+			union[uuid] = append(union[uuid], intervals...)
+		}
+	}
+	for uuid := range union {
+		union[uuid] = normalize(union[uuid])
+	}
+	return union
+}
+
+// Count returns the number of transactions in the set
+func (s GTIDSet) Count() int64 {
+	var count int64
+	for _, intervals := range s {
+		for _, in := range intervals {
+			count += in.end - in.start + 1
+		}
+	}
+	return count
+}
+
+// String returns the set in the format used by the server
+func (s GTIDSet) String() string {
+	uuids := make([]string, 0, len(s))
+	for uuid := range s {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	parts := make([]string, 0, len(uuids))
+	for _, uuid := range uuids {
+		var b strings.Builder
+		b.WriteString(uuid)
+		for _, in := range s[uuid] {
+			if in.start == in.end {
+				fmt.Fprintf(&b, ":%d", in.start)
+			} else {
+				fmt.Fprintf(&b, ":%d-%d", in.start, in.end)
+			}
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// MostAdvanced returns the key whose set contains all the others. When no set
+// is a superset of the rest the one with the most transactions wins, ties are
+// broken by key so the choice is stable across reconciles.
+func MostAdvanced(sets map[string]GTIDSet) string {
+	keys := make([]string, 0, len(sets))
+	for key := range sets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		superset := true
+		for _, other := range keys {
+			if !sets[key].Contains(sets[other]) {
+				superset = false
+				break
+			}
+		}
+		if superset {
+			return key
+		}
+	}
+
+	best := ""
+	for _, key := range keys {
+		if best == "" || sets[key].Count() > sets[best].Count() {
+			best = key
+		}
+	}
+	return best
+}
+
+// normalize sorts and merges overlapping or adjacent intervals
+func normalize(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+
+	merged := intervals[:0]
+	for _, in := range intervals {
+		if n := len(merged); n > 0 && in.start <= merged[n-1].end+1 {
+			if in.end > merged[n-1].end {
+				merged[n-1].end = in.end
+			}
+			continue
+		}
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+// covered returns true if in lies inside one of the normalized intervals
+func covered(intervals []interval, in interval) bool {
+	for _, have := range intervals {
+		if in.start >= have.start && in.end <= have.end {
+			return true
+		}
+	}
+	return false
+}
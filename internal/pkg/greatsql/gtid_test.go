@@ -0,0 +1,78 @@
+package greatsql
+
+import "testing"
+
+const (
+	uuidA = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	uuidB = "8ab1e5d2-71ca-11e1-9e33-c80aa9429562"
+)
+
+func mustParse(t *testing.T, s string) GTIDSet {
+	t.Helper()
+	set, err := ParseGTIDSet(s)
+	if err != nil {
+		t.Fatalf("ParseGTIDSet(%q): %v", s, err)
+	}
+	return set
+}
+
+func TestParseGTIDSet(t *testing.T) {
+	set := mustParse(t, uuidB+":4-6:1-3:9,\n"+uuidA+":5")
+	if got, want := set.String(), uuidA+":5,"+uuidB+":1-6:9"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := set.Count(); got != 8 {
+		t.Errorf("Count() = %d, want 8", got)
+	}
+
+	if _, err := ParseGTIDSet(uuidA + ":x"); err == nil {
+		t.Error("expected an error for a malformed interval")
+	}
+}
+
+func TestContains(t *testing.T) {
+	set := mustParse(t, uuidA+":1-10")
+	cases := []struct {
+		other string
+		want  bool
+	}{
+		{"", true},
+		{uuidA + ":1-10", true},
+		{uuidA + ":3-5:7", true},
+		{uuidA + ":1-11", false},
+		{uuidB + ":1", false},
+	}
+	for _, c := range cases {
+		if got := set.Contains(mustParse(t, c.other)); got != c.want {
+			t.Errorf("Contains(%q) = %v, want %v", c.other, got, c.want)
+		}
+	}
+}
+
+func TestMostAdvanced(t *testing.T) {
+	sets := map[string]GTIDSet{
+		"db-0": mustParse(t, uuidA+":1-10"),
+		"db-1": mustParse(t, uuidA+":1-12"),
+		"db-2": mustParse(t, uuidA+":1-8"),
+	}
+	if got := MostAdvanced(sets); got != "db-1" {
+		t.Errorf("MostAdvanced() = %q, want db-1", got)
+	}
+
+	// diverged sets fall back to the transaction count
+	sets["db-2"] = mustParse(t, uuidA+":1-10,"+uuidB+":1-5")
+	if got := MostAdvanced(sets); got != "db-2" {
+		t.Errorf("MostAdvanced() = %q, want db-2", got)
+	}
+
+	// equal sets pick the first name
+	equal := map[string]GTIDSet{"db-1": GTIDSet{}, "db-0": GTIDSet{}}
+	if got := MostAdvanced(equal); got != "db-0" {
+		t.Errorf("MostAdvanced() = %q, want db-0", got)
+	}
+
+	union := mustParse(t, uuidA+":1-3").Union(mustParse(t, uuidA+":4-6,"+uuidB+":2"))
+	if got, want := union.String(), uuidA+":1-6,"+uuidB+":2"; got != want {
+		t.Errorf("Union() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,231 @@
+package greatsql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-19 10:48:22
+ * @file: replication.go
+ * @description: asynchronous replication operation
+ */
+
+// ReplicationSource describes the server a replica pulls binlogs from
+type ReplicationSource struct {
+	Host     string
+	Port     int32
+	User     string
+	Password string
+	Delay    int32 // SOURCE_DELAY in seconds
+	// TLS encrypts the channel, nil replicates over a plain connection
+	TLS *TLSFiles
+}
+
+// ReplicaStatus is the subset of SHOW REPLICA STATUS the operator relies on
+type ReplicaStatus struct {
+	SourceHost          string
+	IORunning           bool
+	SQLRunning          bool
+	SecondsBehindSource *int64
+	SQLDelay            int32
+	SSLAllowed          bool
+	SQLRunningState     string
+	LastIOError         string
+	LastSQLError        string
+	RetrievedGTIDSet    string
+}
+
+// Running returns true if both replication threads are running
+func (s *ReplicaStatus) Running() bool {
+	return s != nil && s.IORunning && s.SQLRunning
+}
+
+// ApplierIdle returns true if the applier has nothing it may apply yet, either
+// the relay log is applied or the next transaction waits for SOURCE_DELAY
+func (s *ReplicaStatus) ApplierIdle() bool {
+	return s != nil && (strings.Contains(s.SQLRunningState, "_DELAY seconds") || strings.Contains(s.SQLRunningState, "read all relay log"))
+}
+
+// GTIDExecuted returns the gtid_executed set of the server
+func (c *Client) GTIDExecuted(ctx context.Context) (GTIDSet, error) {
+	value, err := c.GetVariable(ctx, "gtid_executed")
+	if err != nil {
+		return nil, err
+	}
+	return ParseGTIDSet(value)
+}
+
+// SetServerID persists a unique server_id, replication refuses to start without one
+func (c *Client) SetServerID(ctx context.Context, id int32) error {
+	return c.Exec(ctx, "SET PERSIST server_id = ?", id)
+}
+
+// IsReadOnly returns true if super_read_only is enabled
+func (c *Client) IsReadOnly(ctx context.Context) (bool, error) {
+	value, err := c.GetVariable(ctx, "super_read_only")
+	if err != nil {
+		return false, err
+	}
+	return value == "1" || value == "ON", nil
+}
+
+// SetReadOnly fences (or unfences) the server for writes. The value is
+// persisted so a restarted member comes back in the same state.
+func (c *Client) SetReadOnly(ctx context.Context, readOnly bool) error {
+	if readOnly {
+		return c.Exec(ctx, "SET PERSIST super_read_only = ON")
+	}
+	if err := c.Exec(ctx, "SET PERSIST super_read_only = OFF"); err != nil {
+		return err
+	}
+	return c.Exec(ctx, "SET PERSIST read_only = OFF")
+}
+
+// Unfence makes the primary writable until it restarts. super_read_only stays
+// persisted, a restarted primary takes no writes before the operator confirmed
+// it is still the primary.
+func (c *Client) Unfence(ctx context.Context) error {
+	// read_only is persisted as well, OFF would clear super_read_only on start
+	if err := c.Exec(ctx, "SET PERSIST_ONLY read_only = ON"); err != nil {
+		return err
+	}
+	if err := c.Exec(ctx, "SET PERSIST_ONLY super_read_only = ON"); err != nil {
+		return err
+	}
+	if err := c.Exec(ctx, "SET GLOBAL super_read_only = OFF"); err != nil {
+		return err
+	}
+	return c.Exec(ctx, "SET GLOBAL read_only = OFF")
+}
+
+// ReplicaStatus returns the status of the default replication channel, nil if
+// the server is not a replica. The group replication channels are ignored.
+func (c *Client) ReplicaStatus(ctx context.Context) (*ReplicaStatus, error) {
+	rows, err := c.queryRows(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		return nil, err
+	}
+
+	var row map[string]string
+	for _, r := range rows {
+		if r["Channel_Name"] == "" {
+			row = r
+		}
+	}
+	if row == nil {
+		return nil, nil
+	}
+	status := &ReplicaStatus{
+		SourceHost:       row["Source_Host"],
+		IORunning:        row["Replica_IO_Running"] == "Yes",
+		SQLRunning:       row["Replica_SQL_Running"] == "Yes",
+		SSLAllowed:       row["Source_SSL_Allowed"] == "Yes",
+		LastIOError:      row["Last_IO_Error"],
+		LastSQLError:     row["Last_SQL_Error"],
+		SQLRunningState:  row["Replica_SQL_Running_State"],
+		RetrievedGTIDSet: row["Retrieved_Gtid_Set"],
+	}
+	if delay, err := strconv.ParseInt(row["SQL_Delay"], 10, 32); err == nil {
+		status.SQLDelay = int32(delay)
+	}
+	if lag, err := strconv.ParseInt(row["Seconds_Behind_Source"], 10, 64); err == nil {
+		status.SecondsBehindSource = &lag
+	}
+	return status, nil
+}
+
+// ChangeReplicationSource points the replica at source using gtid auto positioning.
+// The ssl options are always set so a channel without TLS drops earlier ones.
+func (c *Client) ChangeReplicationSource(ctx context.Context, source ReplicationSource) error {
+	files, enabled := TLSFiles{}, 0
+	if source.TLS != nil {
+		files, enabled = *source.TLS, 1
+	}
+	verify := 0
+	if source.TLS.Mutual() {
+		verify = 1
+	}
+	return c.Exec(ctx, "CHANGE REPLICATION SOURCE TO SOURCE_HOST = ?, SOURCE_PORT = ?, SOURCE_USER = ?, SOURCE_PASSWORD = ?, SOURCE_AUTO_POSITION = 1, GET_SOURCE_PUBLIC_KEY = 1, SOURCE_DELAY = ?, "+
+		"SOURCE_SSL = ?, SOURCE_SSL_CA = ?, SOURCE_SSL_CERT = ?, SOURCE_SSL_KEY = ?, SOURCE_SSL_VERIFY_SERVER_CERT = ?",
+		source.Host, source.Port, source.User, source.Password, source.Delay,
+		enabled, files.CA, files.Cert, files.Key, verify)
+}
+
+// SetReplicationDelay changes SOURCE_DELAY, the applier has to be stopped
+func (c *Client) SetReplicationDelay(ctx context.Context, seconds int32) error {
+	return c.Exec(ctx, "CHANGE REPLICATION SOURCE TO SOURCE_DELAY = ?", seconds)
+}
+
+// StartReplica starts the replication threads
+func (c *Client) StartReplica(ctx context.Context) error {
+	return c.Exec(ctx, "START REPLICA")
+}
+
+// StartApplier starts the applier thread only
+func (c *Client) StartApplier(ctx context.Context) error {
+	return c.Exec(ctx, "START REPLICA SQL_THREAD")
+}
+
+// StartApplierUntilBefore applies the relay log up to, but not including, the
+// first transaction of gtids and stops the applier there
+func (c *Client) StartApplierUntilBefore(ctx context.Context, gtids GTIDSet) error {
+	return c.Exec(ctx, "START REPLICA SQL_THREAD UNTIL SQL_BEFORE_GTIDS = ?", gtids.String())
+}
+
+// StopApplier stops the applier thread, the receiver keeps queueing transactions
+func (c *Client) StopApplier(ctx context.Context) error {
+	return c.Exec(ctx, "STOP REPLICA SQL_THREAD")
+}
+
+// StopReplica stops the replication threads
+func (c *Client) StopReplica(ctx context.Context) error {
+	return c.Exec(ctx, "STOP REPLICA")
+}
+
+// ResetReplicaAll forgets the replication source, used when promoting a replica
+func (c *Client) ResetReplicaAll(ctx context.Context) error {
+	return c.Exec(ctx, "RESET REPLICA ALL")
+}
+
+// WaitForExecutedGTIDSet blocks until the server has applied gtids, it returns
+// false if the timeout elapsed first
+func (c *Client) WaitForExecutedGTIDSet(ctx context.Context, gtids GTIDSet, timeout time.Duration) (bool, error) {
+	var result int
+	err := c.db.QueryRowContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtids.String(), int(timeout.Seconds())).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+	return result == 0, nil
+}
+
+// EnsureReplicationUser creates the account replicas connect with, requireX509
+// only admits clients with a certificate signed by the ssl_ca of the server.
+// Nothing is written when it is up to date so repeated reconciles do not
+// generate transactions.
+func (c *Client) EnsureReplicationUser(ctx context.Context, user, password string, requireX509 bool) error {
+	require := "NONE"
+	if requireX509 {
+		require = "X509"
+	}
+
+	rows, err := c.queryRows(ctx, "SELECT ssl_type FROM mysql.user WHERE user = ? AND host = '%'", user)
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		if (rows[0]["ssl_type"] == "X509") == requireX509 {
+			return nil
+		}
+		return c.Exec(ctx, "ALTER USER ?@'%' REQUIRE "+require, user)
+	}
+
+	if err := c.Exec(ctx, "CREATE USER ?@'%' IDENTIFIED BY ? REQUIRE "+require, user, password); err != nil {
+		return err
+	}
+	// BACKUP_ADMIN lets group members clone a donor during distributed recovery
+	return c.Exec(ctx, "GRANT REPLICATION SLAVE, REPLICATION CLIENT, BACKUP_ADMIN ON *.* TO ?@'%'", user)
+}
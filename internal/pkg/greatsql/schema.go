@@ -0,0 +1,30 @@
+package greatsql
+
+import (
+	"context"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-22 14:31:50
+ * @file: schema.go
+ * @description: schema operation
+ */
+
+// EnsureDatabase creates the schema unless it exists and sets its default
+// character set and collation, the server default collation if it is empty
+func (c *Client) EnsureDatabase(ctx context.Context, name, charset, collation string) error {
+	options := " CHARACTER SET " + charset
+	if collation != "" {
+		options += " COLLATE " + collation
+	}
+	if err := c.Exec(ctx, "CREATE DATABASE IF NOT EXISTS "+quoteName(name)+options); err != nil {
+		return err
+	}
+	return c.Exec(ctx, "ALTER DATABASE "+quoteName(name)+options)
+}
+
+// DropDatabase removes the schema and every table in it if it exists
+func (c *Client) DropDatabase(ctx context.Context, name string) error {
+	return c.Exec(ctx, "DROP DATABASE IF EXISTS "+quoteName(name))
+}
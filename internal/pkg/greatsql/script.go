@@ -0,0 +1,34 @@
+package greatsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-23 09:42:16
+ * @file: script.go
+ * @description: sql scripts
+ */
+
+// ExecScript runs the statements of script, separated by semicolons. It runs on
+// its own connection without read timeout as bootstrap data can take a long time.
+// Client side commands such as DELIMITER or SOURCE are not supported.
+func (c *Client) ExecScript(ctx context.Context, name, script string) error {
+	cfg := c.cfg.Clone()
+	cfg.ReadTimeout = 0
+	cfg.MultiStatements = true
+	cfg.InterpolateParams = false
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("script %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package greatsql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-24 10:48:55
+ * @file: tls.go
+ * @description: tls operation
+ */
+
+// TLSFiles are the certificate files a member connects to another member with,
+// paths on the member itself. Empty files encrypt without authentication.
+type TLSFiles struct {
+	CA   string
+	Cert string
+	Key  string
+}
+
+// Mutual returns true if the member presents a client certificate and verifies
+// the certificate of the server it connects to
+func (f *TLSFiles) Mutual() bool {
+	return f != nil && f.Cert != ""
+}
+
+// sslNotAfterLayout is the format of the Ssl_server_not_after status variable
+const sslNotAfterLayout = "Jan _2 15:04:05 2006 MST"
+
+// TLSNotAfter returns the expiry of the certificate the server currently
+// serves, zero if it serves none
+func (c *Client) TLSNotAfter(ctx context.Context) (time.Time, error) {
+	var name string
+	var value sql.NullString
+	err := c.db.QueryRowContext(ctx, "SHOW GLOBAL STATUS LIKE 'Ssl_server_not_after'").Scan(&name, &value)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+	if !value.Valid || value.String == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(sslNotAfterLayout, value.String)
+}
+
+// ReloadTLS loads the certificate files again, connections already open keep
+// the previous certificate
+func (c *Client) ReloadTLS(ctx context.Context) error {
+	return c.Exec(ctx, "ALTER INSTANCE RELOAD TLS")
+}
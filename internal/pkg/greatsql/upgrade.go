@@ -0,0 +1,80 @@
+package greatsql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-21 11:15:26
+ * @file: upgrade.go
+ * @description: pre-upgrade checks
+ */
+
+// upgradeCheck is one check of the server state an upgrade can not cope with,
+// the query returns the offending objects
+type upgradeCheck struct {
+	problem string
+	query   string
+}
+
+// upgradeChecks follow the errors reported by util.checkForServerUpgrade
+var upgradeChecks = []upgradeCheck{
+	{
+		problem: "partitioned tables using an engine without native partitioning",
+		query: "SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) FROM information_schema.TABLES " +
+			"WHERE CREATE_OPTIONS LIKE '%partitioned%' AND ENGINE NOT IN ('InnoDB', 'ndbcluster')",
+	},
+	{
+		problem: "tables named like internal fulltext index tables",
+		query: "SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) FROM information_schema.TABLES " +
+			"WHERE TABLE_NAME LIKE 'FTS\\_%' AND TABLE_SCHEMA NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema')",
+	},
+	{
+		problem: "schemas or tables whose names the data dictionary can not store",
+		query: "SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) FROM information_schema.TABLES " +
+			"WHERE TABLE_NAME LIKE '%#mysql50#%' OR TABLE_SCHEMA LIKE '%#mysql50#%'",
+	},
+}
+
+// PreUpgradeCheck returns the problems that make an upgrade of the server fail,
+// none if it can be upgraded
+func (c *Client) PreUpgradeCheck(ctx context.Context) ([]string, error) {
+	var problems []string
+	for _, check := range upgradeChecks {
+		rows, err := c.queryRows(ctx, check.query)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		objects := make([]string, 0, len(rows))
+		for _, row := range rows {
+			for _, value := range row {
+				objects = append(objects, value)
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s: %s", check.problem, strings.Join(objects, ", ")))
+	}
+
+	// prepared XA transactions can not be recovered by a different version
+	xa, err := c.queryRows(ctx, "XA RECOVER")
+	if err != nil {
+		return nil, err
+	}
+	if len(xa) > 0 {
+		problems = append(problems, fmt.Sprintf("%d prepared XA transactions, commit or roll them back first", len(xa)))
+	}
+
+	recovery, err := c.GetVariable(ctx, "innodb_force_recovery")
+	if err != nil {
+		return nil, err
+	}
+	if recovery != "0" {
+		problems = append(problems, "innodb_force_recovery is "+recovery+", the server is not in a state to be upgraded")
+	}
+	return problems, nil
+}
@@ -0,0 +1,196 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-23 14:12:07
+ * @file: healthcheck.go
+ * @description: sql checks of the default probes
+ */
+
+// Probe is the kind of probe a check runs for
+type Probe string
+
+const (
+	// Startup passes once the server answers, e.g. after crash recovery
+	Startup Probe = "startup"
+	// Liveness passes while the server answers
+	Liveness Probe = "liveness"
+	// Readiness passes while the server can take its share of the traffic
+	Readiness Probe = "readiness"
+)
+
+// environment of the health check, set on the container by the operator
+const (
+	EnvType          = "HEALTHCHECK_TYPE"
+	EnvStandby       = "HEALTHCHECK_STANDBY"
+	EnvMaxLagSeconds = "HEALTHCHECK_MAX_LAG_SECONDS"
+	EnvLabelsFile    = "HEALTHCHECK_LABELS_FILE"
+	EnvDataDir       = "HEALTHCHECK_DATA_DIR"
+)
+
+// files the clone plugin keeps in the data directory of the recipient while it
+// copies the donor and while the restarted server recovers the copy
+var cloneFiles = []string{"#clone/#status_in_progress", "#clone/#status_recovery"}
+
+// Options describe the member the checks run on
+type Options struct {
+	Type singlev1.GreatSqlType
+	// Role is the role label the operator gave the pod, empty until it is set
+	Role singlev1.MemberRole
+	// Standby replicates into the primary from a primary instance, it is read only
+	Standby bool
+	// MaxLagSeconds a replica may fall behind, 0 disables the check
+	MaxLagSeconds int64
+	// DataDir of the server, the clone files in it are looked up if set
+	DataDir string
+}
+
+// Check runs the checks of probe against the server
+func Check(ctx context.Context, db *greatsql.Client, probe Probe, opts Options) error {
+	if probe != Readiness && Cloning(opts.DataDir) {
+		// the server is replaced by the clone, restarting it loses the copy
+		return nil
+	}
+	if err := db.Ping(ctx); err != nil {
+		// after a clone root has the password of the source until the operator
+		// resets it, the server answers and is alive
+		if probe != Readiness && greatsql.IsAccessDenied(err) {
+			return nil
+		}
+		return fmt.Errorf("server does not answer: %w", err)
+	}
+	if probe != Readiness {
+		return nil
+	}
+
+	readOnly, err := db.IsReadOnly(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Type {
+	case singlev1.GreatSqlTypeSinglePrimaryGroupCluster, singlev1.GreatSqlTypeMultiPrimaryGroupCluster:
+		uuid, err := db.ServerUUID(ctx)
+		if err != nil {
+			return err
+		}
+		members, err := db.GroupMembers(ctx)
+		if err != nil {
+			return err
+		}
+		return checkGroupMember(uuid, members, readOnly)
+	case singlev1.GreatSqlTypeReplicaofCluster:
+		replica, err := db.ReplicaStatus(ctx)
+		if err != nil {
+			return err
+		}
+		return checkReplication(opts, readOnly, replica)
+	}
+	return nil
+}
+
+// Cloning reports whether a clone into dataDir is in progress or recovering
+func Cloning(dataDir string) bool {
+	if dataDir == "" {
+		return false
+	}
+	for _, file := range cloneFiles {
+		if _, err := os.Stat(filepath.Join(dataDir, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGroupMember passes for an ONLINE member whose super_read_only matches
+// the role the group gave it
+func checkGroupMember(uuid string, members []greatsql.GroupMember, readOnly bool) error {
+	for _, m := range members {
+		if m.ID != uuid {
+			continue
+		}
+		if m.State != string(singlev1.MemberStateOnline) {
+			return fmt.Errorf("member is %s", m.State)
+		}
+		if m.Role == "PRIMARY" && readOnly {
+			return fmt.Errorf("primary member is read only")
+		}
+		if m.Role == "SECONDARY" && !readOnly {
+			return fmt.Errorf("secondary member is writable")
+		}
+		return nil
+	}
+	return fmt.Errorf("member is not part of a group")
+}
+
+// checkReplication passes for a writable primary and for a read only replica
+// that replicates within the allowed lag. A member without role is only pinged,
+// the operator has not configured it yet.
+func checkReplication(opts Options, readOnly bool, replica *greatsql.ReplicaStatus) error {
+	switch opts.Role {
+	case "":
+		return nil
+	case singlev1.PrimaryRole:
+		if opts.Standby {
+			// the primary of a standby is a replica of the primary instance
+			return checkLag(opts, replica)
+		}
+		if readOnly {
+			return fmt.Errorf("primary is read only")
+		}
+		return nil
+	}
+
+	if !readOnly {
+		return fmt.Errorf("%s is writable", opts.Role)
+	}
+	return checkLag(opts, replica)
+}
+
+// checkLag passes while both replication threads run and the replica is at most
+// MaxLagSeconds behind, SOURCE_DELAY comes on top
+func checkLag(opts Options, replica *greatsql.ReplicaStatus) error {
+	if !replica.Running() {
+		return fmt.Errorf("replication is not running")
+	}
+	if opts.MaxLagSeconds == 0 {
+		return nil
+	}
+	if replica.SecondsBehindSource == nil {
+		return fmt.Errorf("replication lag is unknown")
+	}
+	if lag := *replica.SecondsBehindSource - int64(replica.SQLDelay); lag > opts.MaxLagSeconds {
+		return fmt.Errorf("replica is %ds behind its source, at most %ds are allowed", lag, opts.MaxLagSeconds)
+	}
+	return nil
+}
+
+// ParseLabels parses the labels file of a downward api volume, one key="value" per line
+func ParseLabels(data string) map[string]string {
+	labels := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		labels[key] = value
+	}
+	return labels
+}
@@ -0,0 +1,88 @@
+package healthcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/greatsql"
+)
+
+func TestCheckReplication(t *testing.T) {
+	lag := func(seconds int64) *int64 { return &seconds }
+	running := func(seconds *int64, delay int32) *greatsql.ReplicaStatus {
+		return &greatsql.ReplicaStatus{IORunning: true, SQLRunning: true, SecondsBehindSource: seconds, SQLDelay: delay}
+	}
+	cases := []struct {
+		name     string
+		opts     Options
+		readOnly bool
+		replica  *greatsql.ReplicaStatus
+		ok       bool
+	}{
+		{"unlabeled", Options{}, false, nil, true},
+		{"writable primary", Options{Role: singlev1.PrimaryRole}, false, nil, true},
+		{"read only primary", Options{Role: singlev1.PrimaryRole}, true, nil, false},
+		{"standby primary", Options{Role: singlev1.PrimaryRole, Standby: true, MaxLagSeconds: 60}, true, running(lag(5), 0), true},
+		{"replica", Options{Role: singlev1.ReplicaofRole, MaxLagSeconds: 60}, true, running(lag(5), 0), true},
+		{"writable replica", Options{Role: singlev1.ReplicaofRole}, false, running(lag(0), 0), false},
+		{"stopped replica", Options{Role: singlev1.ReplicaofRole}, true, nil, false},
+		{"lagging replica", Options{Role: singlev1.ReplicaofRole, MaxLagSeconds: 60}, true, running(lag(61), 0), false},
+		{"lag check disabled", Options{Role: singlev1.ReplicaofRole}, true, running(nil, 0), true},
+		{"delayed replica", Options{Role: singlev1.DelayedRole, MaxLagSeconds: 60}, true, running(lag(3630), 3600), true},
+	}
+	for _, c := range cases {
+		if err := checkReplication(c.opts, c.readOnly, c.replica); (err == nil) != c.ok {
+			t.Errorf("%s: checkReplication() = %v, want ok %v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestCheckGroupMember(t *testing.T) {
+	members := []greatsql.GroupMember{
+		{ID: "a", State: "ONLINE", Role: "PRIMARY"},
+		{ID: "b", State: "ONLINE", Role: "SECONDARY"},
+		{ID: "c", State: "RECOVERING", Role: "SECONDARY"},
+	}
+	cases := []struct {
+		uuid     string
+		readOnly bool
+		ok       bool
+	}{
+		{"a", false, true},
+		{"a", true, false},
+		{"b", true, true},
+		{"b", false, false},
+		{"c", true, false},
+		{"d", true, false},
+	}
+	for _, c := range cases {
+		if err := checkGroupMember(c.uuid, members, c.readOnly); (err == nil) != c.ok {
+			t.Errorf("checkGroupMember(%s, %v) = %v, want ok %v", c.uuid, c.readOnly, err, c.ok)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	labels := ParseLabels("app.kubernetes.io/name=\"single\"\ngreatsql.cn/role=\"primary\"\n")
+	if labels["greatsql.cn/role"] != "primary" || labels["app.kubernetes.io/name"] != "single" {
+		t.Errorf("ParseLabels() = %v", labels)
+	}
+}
+
+func TestCloning(t *testing.T) {
+	dir := t.TempDir()
+	if Cloning("") || Cloning(dir) {
+		t.Fatalf("Cloning() without clone files = true")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "#clone"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "#clone", "#status_recovery"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !Cloning(dir) {
+		t.Errorf("Cloning() while recovering = false")
+	}
+}
@@ -3,7 +3,10 @@ package kube
 import (
 	"crypto/sha256"
 	"fmt"
+	"sort"
+	"strings"
 
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -144,7 +147,7 @@ performance_schema_instrument = '%lock%=on'
 `
 
 // ConfigMap returns a ConfigMap object
-func NewConfigMap(name, namespace string) *corev1.ConfigMap {
+func NewConfigMap(single *singlev1.Single, name string) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -152,14 +155,69 @@ func NewConfigMap(name, namespace string) *corev1.ConfigMap {
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: namespace,
+			Namespace: single.Namespace,
 		},
 		Data: map[string]string{
-			"my.cnf": data,
+			"my.cnf": setMysqldOptions(data, volumeOptions(single)),
 		},
 	}
 }
 
-func GetConfigDataHash() string {
-	return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+// volumeOptions returns the mysqld path options pointing into the additional volumes
+func volumeOptions(single *singlev1.Single) map[string]string {
+	options := map[string]string{}
+	storage := single.Spec.PodSpec.Storage
+	if storage == nil {
+		return options
+	}
+
+	if storage.BinlogVolume != nil {
+		options["log_bin"] = BinlogDir + "/binlog"
+		options["relay_log"] = BinlogDir + "/relay"
+	}
+	if storage.RedoVolume != nil {
+		options["innodb_log_group_home_dir"] = RedoDir
+		options["innodb_undo_directory"] = RedoDir
+	}
+	if storage.LogVolume != nil {
+		options["log_error"] = LogDir + "/error.log"
+		options["slow_query_log_file"] = LogDir + "/slow.log"
+	}
+	return options
+}
+
+// setMysqldOptions replaces the given options in the my.cnf, options it does not
+// contain are appended to the [mysqld] section which is the last one
+func setMysqldOptions(cnf string, options map[string]string) string {
+	lines := strings.Split(strings.TrimRight(cnf, "\n"), "\n")
+	set := map[string]bool{}
+	for i, line := range lines {
+		key, _, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if value, ok := options[key]; ok {
+			lines[i] = key + " = " + value
+			set[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		if !set[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+" = "+options[key])
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ConfigDataHash returns the hash of the my.cnf generated for the single. The pod
+// template carries it, a changed my.cnf restarts the pods like a changed template.
+func ConfigDataHash(single *singlev1.Single) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(setMysqldOptions(data, volumeOptions(single)))))
 }
@@ -0,0 +1,95 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+)
+
+func TestSetMysqldOptions(t *testing.T) {
+	cnf := "[client]\nport = 3306\n[mysqld]\n#log_bin = /old\nlog_bin = /data/binlog\nport=3306\n"
+	cases := []struct {
+		name    string
+		options map[string]string
+		want    string
+	}{
+		{"no options", nil, cnf},
+		{
+			"replaced keys",
+			map[string]string{"log_bin": "/binlog/binlog"},
+			"[client]\nport = 3306\n[mysqld]\n#log_bin = /old\nlog_bin = /binlog/binlog\nport=3306\n",
+		},
+		{
+			"appended keys are sorted",
+			map[string]string{"relay_log": "/binlog/relay", "innodb_undo_directory": "/redo"},
+			cnf + "innodb_undo_directory = /redo\nrelay_log = /binlog/relay\n",
+		},
+		{
+			"replaced and appended keys",
+			map[string]string{"log_bin": "/binlog/binlog", "relay_log": "/binlog/relay"},
+			"[client]\nport = 3306\n[mysqld]\n#log_bin = /old\nlog_bin = /binlog/binlog\nport=3306\nrelay_log = /binlog/relay\n",
+		},
+	}
+	for _, c := range cases {
+		if got := setMysqldOptions(cnf, c.options); got != c.want {
+			t.Errorf("%s: setMysqldOptions() = %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	// an option is replaced in every section that sets it
+	if got, want := setMysqldOptions(cnf, map[string]string{"port": "3307"}), "[client]\nport = 3307\n[mysqld]\n#log_bin = /old\nlog_bin = /data/binlog\nport = 3307\n"; got != want {
+		t.Errorf("setMysqldOptions(port) = %q, want %q", got, want)
+	}
+}
+
+func TestVolumeOptions(t *testing.T) {
+	volume := &corev1.PersistentVolumeClaimSpec{}
+	binlog := map[string]string{"log_bin": BinlogDir + "/binlog", "relay_log": BinlogDir + "/relay"}
+	redo := map[string]string{"innodb_log_group_home_dir": RedoDir, "innodb_undo_directory": RedoDir}
+	logs := map[string]string{"log_error": LogDir + "/error.log", "slow_query_log_file": LogDir + "/slow.log"}
+	merge := func(maps ...map[string]string) map[string]string {
+		merged := map[string]string{}
+		for _, m := range maps {
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	cases := []struct {
+		name    string
+		storage *singlev1.Storage
+		want    map[string]string
+	}{
+		{"no storage", nil, map[string]string{}},
+		{"data volume only", &singlev1.Storage{}, map[string]string{}},
+		{"binlog", &singlev1.Storage{BinlogVolume: volume}, binlog},
+		{"redo", &singlev1.Storage{RedoVolume: volume}, redo},
+		{"log", &singlev1.Storage{LogVolume: volume}, logs},
+		{"binlog and log", &singlev1.Storage{BinlogVolume: volume, LogVolume: volume}, merge(binlog, logs)},
+		{"all", &singlev1.Storage{BinlogVolume: volume, RedoVolume: volume, LogVolume: volume}, merge(binlog, redo, logs)},
+	}
+	for _, c := range cases {
+		single := &singlev1.Single{Spec: singlev1.SingleSpec{PodSpec: singlev1.PodSpec{Storage: c.storage}}}
+		if got := volumeOptions(single); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: volumeOptions() = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// the default my.cnf sets the binlog and log paths, they are replaced in place
+	single := &singlev1.Single{Spec: singlev1.SingleSpec{PodSpec: singlev1.PodSpec{Storage: &singlev1.Storage{BinlogVolume: volume, LogVolume: volume}}}}
+	cnf := setMysqldOptions(data, volumeOptions(single))
+	for _, line := range []string{"\nlog_bin = " + BinlogDir + "/binlog\n", "\nlog_error = " + LogDir + "/error.log\n", "\nslow_query_log_file = " + LogDir + "/slow.log\n"} {
+		if !strings.Contains(cnf, line) {
+			t.Errorf("my.cnf does not contain %q", line)
+		}
+	}
+	if strings.Contains(cnf, "\nlog_bin = /data/GreatSQL/binlog\n") {
+		t.Error("my.cnf still sets the binlog on the data volume")
+	}
+}
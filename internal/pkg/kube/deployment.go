@@ -28,7 +28,13 @@ func NewDeployment(single *singlev1.Single, configMapName string) *appsv1.Deploy
 
 	affinity := setAffinity(single, labels)
 
-	return &appsv1.Deployment{
+	// a stopped single has no pod, its claim is not owned by the deployment
+	replicas := single.Spec.Size
+	if single.Spec.Stopped {
+		replicas = new(int32)
+	}
+
+	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
 			Kind:       "Deployment",
@@ -46,12 +52,14 @@ func NewDeployment(single *singlev1.Single, configMapName string) *appsv1.Deploy
 			Labels: labels,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: single.Spec.Size,
+			Replicas: replicas,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: map[string]string{consts.ConfigMapDataHash: ConfigDataHash(single)},
 				},
 				Spec: corev1.PodSpec{
+					InitContainers:                NewInitContainers(single),
 					Containers:                    NewContainers(single),
 					TerminationGracePeriodSeconds: single.Spec.PodSpec.TerminationGracePeriodSeconds,
 					SchedulerName:                 single.Spec.PodSpec.SchedulerName,
@@ -60,7 +68,7 @@ func NewDeployment(single *singlev1.Single, configMapName string) *appsv1.Deploy
 					SecurityContext:               single.Spec.PodSpec.PodSecurityContext,
 					NodeSelector:                  single.Spec.PodSpec.NodeSelector,
 					Tolerations:                   single.Spec.PodSpec.Tolerations,
-					Volumes: []corev1.Volume{
+					Volumes: append([]corev1.Volume{
 						{
 							Name: single.Name + "-config",
 							VolumeSource: corev1.VolumeSource{
@@ -73,14 +81,10 @@ func NewDeployment(single *singlev1.Single, configMapName string) *appsv1.Deploy
 							},
 						},
 						{
-							Name: single.Name + "-db",
-							VolumeSource: corev1.VolumeSource{
-								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: single.Name + "-db",
-								},
-							},
+							Name:         DataVolumeName(single),
+							VolumeSource: NewDataVolumeSource(single),
 						},
-					},
+					}, append(append(newAdditionalVolumes(single), newHealthCheckVolumes()...), newTLSVolumes(single)...)...),
 					DNSPolicy: single.Spec.DnsPolicy,
 				},
 			},
@@ -94,6 +98,8 @@ func NewDeployment(single *singlev1.Single, configMapName string) *appsv1.Deploy
 			},
 		},
 	}
+	deployment.Annotations = map[string]string{consts.TemplateHash: TemplateHash(deployment.Spec.Template)}
+	return deployment
 }
 
 // setAffinity set affinity and anti-affinity
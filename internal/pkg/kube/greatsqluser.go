@@ -0,0 +1,40 @@
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-22 15:12:40
+ * @file: greatsqluser.go
+ * @description: greatsqluser operation
+ */
+
+// NewDatabaseUser returns the GreatSQLUser of a generated account of a GreatSQLDatabase,
+// its password is read from the secret of the account
+func NewDatabaseUser(database *singlev1.GreatSQLDatabase, role, username string, grants []singlev1.Grant) *singlev1.GreatSQLUser {
+	return &singlev1.GreatSQLUser{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "GreatSQLUser",
+			APIVersion: singlev1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            database.Name + "-" + role,
+			Namespace:       database.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(database, singlev1.GroupVersion.WithKind("GreatSQLDatabase"))},
+		},
+		Spec: singlev1.GreatSQLUserSpec{
+			SingleRef: database.Spec.SingleRef,
+			Username:  username,
+			PasswordSecret: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: DatabaseSecretName(database, role)},
+				Key:                  "password",
+			},
+			Grants: grants,
+		},
+	}
+}
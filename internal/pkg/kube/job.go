@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"strconv"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 18:24:37
+ * @file: job.go
+ * @description: job operation
+ */
+
+// importScript dumps the user schemas of the source into the single. The gtid set
+// of the dump is kept so the single can replicate from the source afterwards.
+const importScript = `set -e -o pipefail
+dbs=$(mysql -h "$SOURCE_HOST" -P "$SOURCE_PORT" -u "$SOURCE_USER" -p"$SOURCE_PASSWORD" -N -e \
+  "SELECT schema_name FROM information_schema.schemata WHERE schema_name NOT IN ('mysql','sys','information_schema','performance_schema')")
+mysqldump -h "$SOURCE_HOST" -P "$SOURCE_PORT" -u "$SOURCE_USER" -p"$SOURCE_PASSWORD" \
+  --single-transaction --routines --triggers --events --set-gtid-purged=ON --databases $dbs \
+  | mysql -h "$TARGET_HOST" -P "$TARGET_PORT" -u root -p"$MYSQL_ROOT_PASSWORD"
+`
+
+// ImportJobName returns the name of the job dumping an external source into the single
+func ImportJobName(single *singlev1.Single) string {
+	return single.Name + "-import"
+}
+
+// NewImportJob returns the job dumping dataSource.external into the single,
+// rootPassword is the MYSQL_ROOT_PASSWORD env of the single
+func NewImportJob(single *singlev1.Single, rootPassword corev1.EnvVar) *batchv1.Job {
+	external := single.Spec.DataSource.External
+	host, port := ServiceAddress(single)
+	credential := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: external.CredentialsSecret},
+				Key:                  key,
+			},
+		}
+	}
+
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ImportJobName(single),
+			Namespace:       single.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*NewOwnerReference(single)},
+			Labels:          NewLabels(single),
+		},
+		Spec: batchv1.JobSpec{
+			// a partial import can not be repeated, the gtid set is already purged
+			BackoffLimit: &[]int32{0}[0],
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:            "import",
+							Image:           single.Spec.PodSpec.Image,
+							ImagePullPolicy: single.Spec.PodSpec.ImagePullPolicy,
+							Command:         []string{"bash", "-c", importScript},
+							Env: []corev1.EnvVar{
+								{Name: "SOURCE_HOST", Value: external.Host},
+								{Name: "SOURCE_PORT", Value: strconv.Itoa(int(external.Port))},
+								{Name: "SOURCE_USER", ValueFrom: credential("user")},
+								{Name: "SOURCE_PASSWORD", ValueFrom: credential("password")},
+								{Name: "TARGET_HOST", Value: host},
+								{Name: "TARGET_PORT", Value: strconv.Itoa(int(port))},
+								rootPassword,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
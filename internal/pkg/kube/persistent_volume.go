@@ -18,16 +18,33 @@ var (
 	volumeMode = corev1.PersistentVolumeFilesystem
 )
 
-// NewPersistentVolume returns a new persistent volume
+// PersistentVolumeName returns the name of the statically provisioned volume,
+// persistentVolumes are cluster scoped so the namespace is part of the name
+func PersistentVolumeName(singleGreatsql *singlev1.Single) string {
+	return singleGreatsql.Namespace + "-" + DataVolumeName(singleGreatsql)
+}
+
+// NewPersistentVolume returns a new persistent volume pre-bound to the data claim
 func NewPersistentVolume(singleGreatsql *singlev1.Single) *corev1.PersistentVolume {
 
+	storage := singleGreatsql.Spec.PodSpec.Storage
+	reclaimPolicy := storage.ReclaimPolicy
+	if reclaimPolicy == "" {
+		reclaimPolicy = corev1.PersistentVolumeReclaimRetain
+	}
+	var storageClassName string
+	if storage.PersistentVolumeClaimTemplate != nil && storage.PersistentVolumeClaimTemplate.StorageClassName != nil {
+		storageClassName = *storage.PersistentVolumeClaimTemplate.StorageClassName
+	}
+
 	persistentVolume := &corev1.PersistentVolume{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "PersistentVolume",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: singleGreatsql.Name + "-db",
+			Name:   PersistentVolumeName(singleGreatsql),
+			Labels: NewLabels(singleGreatsql),
 		},
 		Spec: corev1.PersistentVolumeSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{
@@ -36,10 +53,17 @@ func NewPersistentVolume(singleGreatsql *singlev1.Single) *corev1.PersistentVolu
 			Capacity: corev1.ResourceList{
 				corev1.ResourceStorage: *setDefaultStorage(singleGreatsql),
 			},
-			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRecycle,
-			StorageClassName:              *singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeClaimTemplate.StorageClassName,
+			// only the data claim of this single may bind the volume
+			ClaimRef: &corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+				Namespace:  singleGreatsql.Namespace,
+				Name:       DataVolumeName(singleGreatsql),
+			},
+			PersistentVolumeReclaimPolicy: reclaimPolicy,
+			StorageClassName:              storageClassName,
 			VolumeMode:                    &volumeMode,
-			PersistentVolumeSource:        *singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeSource,
+			PersistentVolumeSource:        *storage.PersistentVolumeSource,
 		},
 	}
 
@@ -58,7 +82,7 @@ func NewPersistentVolumeClaim(singleGreatsql *singlev1.Single) *corev1.Persisten
 			Kind:       "PersistentVolumeClaim",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      singleGreatsql.Name + "-db",
+			Name:      DataVolumeName(singleGreatsql),
 			Namespace: singleGreatsql.Namespace,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
@@ -75,9 +99,25 @@ func NewPersistentVolumeClaim(singleGreatsql *singlev1.Single) *corev1.Persisten
 		},
 	}
 
+	// populate from a volumeSnapshot or another claim
+	if template := singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeClaimTemplate; template != nil {
+		persistentVolumeClaim.Spec.DataSource = template.DataSource
+		persistentVolumeClaim.Spec.DataSourceRef = template.DataSourceRef
+	}
+
+	// bind to the statically provisioned volume instead of provisioning one
+	if singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeSource != nil {
+		persistentVolumeClaim.Spec.VolumeName = PersistentVolumeName(singleGreatsql)
+	}
+
 	return persistentVolumeClaim
 }
 
+// StorageRequest returns the requested size of the data volume
+func StorageRequest(singleGreatsql *singlev1.Single) resource.Quantity {
+	return *setDefaultStorage(singleGreatsql)
+}
+
 // setDefaultStorage set default storage
 func setDefaultStorage(singleGreatsql *singlev1.Single) *resource.Quantity {
 	storageQuantity := resource.NewQuantity(singleGreatsql.Spec.PodSpec.Storage.PersistentVolumeClaimTemplate.Resources.Requests.Storage().Value(), resource.BinarySI)
@@ -1,7 +1,12 @@
 package kube
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
 	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/healthcheck"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -12,6 +17,13 @@ import (
  * @description: kubernetes pod operation
  */
 
+// TemplateHash returns the hash of a generated pod template. It is compared
+// instead of the stored template, which the api server fills defaults into.
+func TemplateHash(template corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(template)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
 // NewContainers returns a new container
 func NewContainers(app *singlev1.Single) []corev1.Container {
 	containerPorts := []corev1.ContainerPort{}
@@ -25,24 +37,25 @@ func NewContainers(app *singlev1.Single) []corev1.Container {
 			Name:            app.Name,
 			Image:           app.Spec.PodSpec.Image,
 			Resources:       app.Spec.PodSpec.Resources,
-			StartupProbe:    &app.Spec.PodSpec.StartupProbe,
-			ReadinessProbe:  &app.Spec.PodSpec.ReadinessProbe,
-			LivenessProbe:   &app.Spec.PodSpec.LivenessProbe,
+			StartupProbe:    newProbe(app.Spec.PodSpec.StartupProbe, newHealthCheckProbe(healthcheck.Startup, 10, 360)),
+			ReadinessProbe:  newProbe(app.Spec.PodSpec.ReadinessProbe, newHealthCheckProbe(healthcheck.Readiness, 5, 3)),
+			LivenessProbe:   newProbe(app.Spec.PodSpec.LivenessProbe, newHealthCheckProbe(healthcheck.Liveness, 10, 3)),
 			SecurityContext: app.Spec.PodSpec.SecurityContext,
 			Ports:           containerPorts,
 			ImagePullPolicy: app.Spec.PodSpec.ImagePullPolicy,
-			Env:             app.Spec.PodSpec.Envs,
-			VolumeMounts: []corev1.VolumeMount{
+			Env:             append(append([]corev1.EnvVar{}, app.Spec.PodSpec.Envs...), newHealthCheckEnv(app)...),
+			Args:            newTLSArgs(app),
+			VolumeMounts: append([]corev1.VolumeMount{
 				{
 					Name:      app.Name + "-config",
 					MountPath: "/etc/my.cnf",
 					SubPath:   "my.cnf",
 				},
 				{
-					Name:      app.Name + "-db",
+					Name:      DataVolumeName(app),
 					MountPath: "/data",
 				},
-			},
+			}, append(append(newAdditionalVolumeMounts(app), newHealthCheckVolumeMounts()...), newTLSVolumeMounts(app)...)...),
 		},
 	}
 }
@@ -54,3 +67,30 @@ func GetNodeName(pod *corev1.Pod) string {
 	}
 	return ""
 }
+
+// NewInitContainers returns the init containers of the pod. The health check
+// binary of the default probes is copied next to the server. A data volume
+// restored from a snapshot carries the server_uuid of its source, auto.cnf is
+// removed once so the restored server generates its own.
+func NewInitContainers(app *singlev1.Single) []corev1.Container {
+	containers := newHealthCheckInitContainer()
+	if !app.Spec.RestoresFromDataSource() {
+		return containers
+	}
+
+	marker := "/data/.restored-for"
+	script := fmt.Sprintf(`[ "$(cat %[1]s 2>/dev/null)" = "%[2]s" ] || { rm -f /data/GreatSQL/auto.cnf; echo "%[2]s" > %[1]s; }`, marker, app.UID)
+	return append(containers, corev1.Container{
+		Name:            "reset-server-uuid",
+		Image:           app.Spec.PodSpec.Image,
+		ImagePullPolicy: app.Spec.PodSpec.ImagePullPolicy,
+		Command:         []string{"sh", "-c", script},
+		SecurityContext: app.Spec.PodSpec.SecurityContext,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      DataVolumeName(app),
+				MountPath: "/data",
+			},
+		},
+	})
+}
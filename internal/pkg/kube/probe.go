@@ -0,0 +1,141 @@
+package kube
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/pkg/healthcheck"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-23 15:40:26
+ * @file: probe.go
+ * @description: default probes of the greatsql container
+ */
+
+// HealthCheckImage is the operator image shipping the health check binary of the
+// default probes. Without it only the probes of podSpec are set.
+var HealthCheckImage string
+
+const (
+	healthCheckVolume = "healthcheck"
+	healthCheckDir    = "/opt/greatsql-operator"
+	healthCheckBinary = healthCheckDir + "/greatsql-healthcheck"
+	podInfoVolume     = "podinfo"
+	podInfoDir        = "/etc/podinfo"
+)
+
+// newProbe returns probe if it has a handler, otherwise the default probe with
+// the timings of probe that are set
+func newProbe(probe corev1.Probe, defaults *corev1.Probe) *corev1.Probe {
+	if defaults == nil || probe.Exec != nil || probe.HTTPGet != nil || probe.TCPSocket != nil || probe.GRPC != nil {
+		return &probe
+	}
+	merged := *defaults
+	if probe.InitialDelaySeconds != 0 {
+		merged.InitialDelaySeconds = probe.InitialDelaySeconds
+	}
+	if probe.TimeoutSeconds != 0 {
+		merged.TimeoutSeconds = probe.TimeoutSeconds
+	}
+	if probe.PeriodSeconds != 0 {
+		merged.PeriodSeconds = probe.PeriodSeconds
+	}
+	if probe.SuccessThreshold != 0 {
+		merged.SuccessThreshold = probe.SuccessThreshold
+	}
+	if probe.FailureThreshold != 0 {
+		merged.FailureThreshold = probe.FailureThreshold
+	}
+	return &merged
+}
+
+// newHealthCheckProbe returns the default probe running the health check binary,
+// nil without a health check image. The startup probe allows for a long crash
+// recovery, the liveness probe only starts after it.
+func newHealthCheckProbe(probe healthcheck.Probe, periodSeconds, failureThreshold int32) *corev1.Probe {
+	if HealthCheckImage == "" {
+		return nil
+	}
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: []string{healthCheckBinary, string(probe)}},
+		},
+		TimeoutSeconds:   10,
+		PeriodSeconds:    periodSeconds,
+		SuccessThreshold: 1,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+// newHealthCheckEnv returns the env the health check binary reads
+func newHealthCheckEnv(app *singlev1.Single) []corev1.EnvVar {
+	if HealthCheckImage == "" {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: healthcheck.EnvType, Value: string(app.Spec.GreatSqlType)},
+		{Name: healthcheck.EnvStandby, Value: strconv.FormatBool(app.Spec.IsStandby())},
+		{Name: healthcheck.EnvMaxLagSeconds, Value: strconv.Itoa(int(app.Spec.HealthCheck.MaxReplicationLagSeconds))},
+		{Name: healthcheck.EnvLabelsFile, Value: podInfoDir + "/labels"},
+		{Name: healthcheck.EnvDataDir, Value: DataDir},
+	}
+}
+
+// newHealthCheckVolumeMounts returns the mounts of the health check binary and
+// of the pod labels holding the role
+func newHealthCheckVolumeMounts() []corev1.VolumeMount {
+	if HealthCheckImage == "" {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{Name: healthCheckVolume, MountPath: healthCheckDir, ReadOnly: true},
+		{Name: podInfoVolume, MountPath: podInfoDir, ReadOnly: true},
+	}
+}
+
+// newHealthCheckVolumes returns the volumes of the health check binary and of
+// the pod labels, the labels file follows the role label
+func newHealthCheckVolumes() []corev1.Volume {
+	if HealthCheckImage == "" {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name:         healthCheckVolume,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		{
+			Name: podInfoVolume,
+			VolumeSource: corev1.VolumeSource{
+				DownwardAPI: &corev1.DownwardAPIVolumeSource{
+					Items: []corev1.DownwardAPIVolumeFile{
+						{Path: "labels", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newHealthCheckInitContainer returns the init container copying the health check
+// binary out of the operator image, nil without a health check image
+func newHealthCheckInitContainer() []corev1.Container {
+	if HealthCheckImage == "" {
+		return nil
+	}
+	return []corev1.Container{
+		{
+			Name:            "install-healthcheck",
+			Image:           HealthCheckImage,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"/greatsql-healthcheck", "install", healthCheckDir},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: healthCheckVolume, MountPath: healthCheckDir},
+			},
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package kube
 
 import (
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -87,3 +88,70 @@ func NewSecretEnvFrom(envFromRefs []corev1.EnvFromSource, req ctrl.Request) *cor
 	}
 	return secret
 }
+
+// InternalSecretName returns the name of the secret holding the operator managed credentials
+func InternalSecretName(single *singlev1.Single) string {
+	return single.Name + "-internal"
+}
+
+// NewInternalSecret returns the secret holding the operator managed credentials
+func NewInternalSecret(single *singlev1.Single, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            InternalSecretName(single),
+			Namespace:       single.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*NewOwnerReference(single)},
+			Labels:          NewLabels(single),
+		},
+		Data: data,
+		Type: corev1.SecretTypeOpaque,
+	}
+}
+
+// CredentialsSecretName returns the name of the secret holding the generated password of a GreatSQLUser
+func CredentialsSecretName(user *singlev1.GreatSQLUser) string {
+	return user.Name + "-credentials"
+}
+
+// NewCredentialsSecret returns the secret holding the generated credentials of a GreatSQLUser
+func NewCredentialsSecret(user *singlev1.GreatSQLUser, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CredentialsSecretName(user),
+			Namespace:       user.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(user, singlev1.GroupVersion.WithKind("GreatSQLUser"))},
+		},
+		Data: data,
+		Type: corev1.SecretTypeOpaque,
+	}
+}
+
+// DatabaseSecretName returns the name of the secret holding the credentials of a generated account of a GreatSQLDatabase
+func DatabaseSecretName(database *singlev1.GreatSQLDatabase, role string) string {
+	return database.Name + "-" + role
+}
+
+// NewDatabaseSecret returns the secret holding the credentials of a generated account of a GreatSQLDatabase
+func NewDatabaseSecret(database *singlev1.GreatSQLDatabase, role string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            DatabaseSecretName(database, role),
+			Namespace:       database.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(database, singlev1.GroupVersion.WithKind("GreatSQLDatabase"))},
+		},
+		Data: data,
+		Type: corev1.SecretTypeOpaque,
+	}
+}
@@ -19,6 +19,15 @@ import (
 func NewService(app *singlev1.Single) *corev1.Service {
 	svcType := corev1.ServiceTypeClusterIP
 
+	selector := map[string]string{
+		consts.AppKubernetesComponent: "controller",
+		consts.AppKubernetesName:      app.Name,
+	}
+	// replicated topologies only route to the members holding the primary role
+	if app.Spec.IsCluster() {
+		selector[consts.GreatSqlRole] = string(singlev1.PrimaryRole)
+	}
+
 	switch app.Spec.Type {
 	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
 		svcType = app.Spec.Type
@@ -45,12 +54,88 @@ func NewService(app *singlev1.Single) *corev1.Service {
 			},
 		},
 		Spec: corev1.ServiceSpec{
-			Type:  svcType,
-			Ports: app.Spec.Ports,
-			Selector: map[string]string{
-				consts.AppKubernetesComponent: "controller",
-				consts.AppKubernetesName:      app.Name,
-			},
+			Type:     svcType,
+			Ports:    app.Spec.Ports,
+			Selector: selector,
+		},
+	}
+}
+
+// HeadlessServiceName returns the name of the headless service governing the statefulset
+func HeadlessServiceName(app *singlev1.Single) string {
+	return app.Name + "-headless"
+}
+
+// NewHeadlessService returns the headless service giving every member a stable dns name
+func NewHeadlessService(app *singlev1.Single) *corev1.Service {
+	labels := NewLabels(app)
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            HeadlessServiceName(app),
+			Namespace:       app.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*NewOwnerReference(app)},
+			Labels:          labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                corev1.ClusterIPNone,
+			Ports:                    app.Spec.Ports,
+			Selector:                 labels,
+			PublishNotReadyAddresses: true,
 		},
 	}
 }
+
+// NewReadService returns the service load balancing reads over the replicas,
+// every member serves reads in multi primary groups
+func NewReadService(app *singlev1.Single) *corev1.Service {
+	labels := NewLabels(app)
+	selector := NewLabels(app)
+	switch app.Spec.GreatSqlType {
+	case singlev1.GreatSqlTypeReplicaofCluster:
+		selector[consts.GreatSqlRole] = string(singlev1.ReplicaofRole)
+	case singlev1.GreatSqlTypeSinglePrimaryGroupCluster:
+		selector[consts.GreatSqlRole] = string(singlev1.SencondaryRole)
+	}
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            app.Name + "-read",
+			Namespace:       app.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*NewOwnerReference(app)},
+			Labels:          labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Ports:    app.Spec.Ports,
+			Selector: selector,
+		},
+	}
+}
+
+// ServiceAddress returns the dns name and port of the service routing to the primary
+func ServiceAddress(app *singlev1.Single) (string, int32) {
+	port := int32(3306)
+	if len(app.Spec.Ports) > 0 {
+		port = app.Spec.Ports[0].Port
+	}
+	return app.Name + "." + app.Namespace + ".svc", port
+}
+
+// ReadServiceAddress returns the dns name and port of the service routing reads,
+// a single reads from the service of its only pod
+func ReadServiceAddress(app *singlev1.Single) (string, int32) {
+	host, port := ServiceAddress(app)
+	if app.Spec.IsCluster() {
+		host = app.Name + "-read." + app.Namespace + ".svc"
+	}
+	return host, port
+}
@@ -2,6 +2,7 @@ package kube
 
 import (
 	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	"github.com/keington/greatsql-operator/internal/consts"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,7 +15,31 @@ import (
  * @description: statefulset operation
  */
 
-func NewStatefulSet(singleGreatsql *singlev1.Single) *appsv1.StatefulSet {
+// NewStatefulSet returns the statefulset backing the replicated topologies
+func NewStatefulSet(singleGreatsql *singlev1.Single, configMapName string) *appsv1.StatefulSet {
+	labels := NewLabels(singleGreatsql)
+
+	// members advertise their stable dns name, replicas and group members
+	// connect back to each other through it
+	containers := NewContainers(singleGreatsql)
+	containers[0].Env = append(append([]corev1.EnvVar{}, containers[0].Env...), corev1.EnvVar{
+		Name: "POD_NAME",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+		},
+	})
+	containers[0].Args = append(containers[0].Args, "--report-host="+PodFQDN(singleGreatsql, "$(POD_NAME)"))
+
+	replicas := singleGreatsql.Spec.Size
+	whenScaled := appsv1.RetainPersistentVolumeClaimRetentionPolicyType
+	if singleGreatsql.Spec.Scaling.DeletePVCOnScaleIn {
+		whenScaled = appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+	}
+	// a stopped cluster has no pods, its claims are kept for the next start
+	if singleGreatsql.Spec.Stopped {
+		replicas = new(int32)
+		whenScaled = appsv1.RetainPersistentVolumeClaimRetentionPolicyType
+	}
 
 	statefulSet := &appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
@@ -22,26 +47,99 @@ func NewStatefulSet(singleGreatsql *singlev1.Single) *appsv1.StatefulSet {
 			Kind:       "StatefulSet",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      singleGreatsql.Name + "-statefulset",
-			Namespace: singleGreatsql.Namespace,
+			Name:            singleGreatsql.Name,
+			Namespace:       singleGreatsql.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*NewOwnerReference(singleGreatsql)},
+			Labels:          labels,
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas: singleGreatsql.Spec.Size,
+			Replicas:            replicas,
+			ServiceName:         HeadlessServiceName(singleGreatsql),
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			// the operator restarts the members one at a time, the primary last
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.OnDeleteStatefulSetStrategyType,
+			},
+			PersistentVolumeClaimRetentionPolicy: &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+				WhenDeleted: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+				WhenScaled:  whenScaled,
+			},
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": singleGreatsql.Name,
-				},
+				MatchLabels: labels,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": singleGreatsql.Name,
-					},
+					Labels:      labels,
+					Annotations: map[string]string{consts.ConfigMapDataHash: ConfigDataHash(singleGreatsql)},
+				},
+				Spec: corev1.PodSpec{
+					InitContainers:                NewInitContainers(singleGreatsql),
+					Containers:                    containers,
+					TerminationGracePeriodSeconds: singleGreatsql.Spec.PodSpec.TerminationGracePeriodSeconds,
+					SchedulerName:                 singleGreatsql.Spec.PodSpec.SchedulerName,
+					Affinity:                      setAffinity(singleGreatsql, labels),
+					ServiceAccountName:            singleGreatsql.Spec.PodSpec.ServiceAccountName,
+					SecurityContext:               singleGreatsql.Spec.PodSpec.PodSecurityContext,
+					NodeSelector:                  singleGreatsql.Spec.PodSpec.NodeSelector,
+					Tolerations:                   singleGreatsql.Spec.PodSpec.Tolerations,
+					Volumes: append([]corev1.Volume{
+						{
+							Name: singleGreatsql.Name + "-config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: configMapName,
+									},
+									DefaultMode: &[]int32{0664}[0],
+								},
+							},
+						},
+					}, append(newHealthCheckVolumes(), newTLSVolumes(singleGreatsql)...)...),
+					DNSPolicy: singleGreatsql.Spec.DnsPolicy,
 				},
 			},
-			// TODO: add volumeClaimTemplates
 		},
 	}
 
+	// every member gets its own claims, an emptyDir is mounted as is.
+	// The data claim comes first, the additional claims follow.
+	var claims []*corev1.PersistentVolumeClaim
+	if singleGreatsql.Spec.NeedsPersistentVolumeClaim() {
+		claims = append(claims, NewPersistentVolumeClaim(singleGreatsql))
+	} else {
+		statefulSet.Spec.Template.Spec.Volumes = append(statefulSet.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name:         DataVolumeName(singleGreatsql),
+			VolumeSource: NewDataVolumeSource(singleGreatsql),
+		})
+	}
+	for _, pvc := range append(claims, NewAdditionalClaims(singleGreatsql)...) {
+		statefulSet.Spec.VolumeClaimTemplates = append(statefulSet.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   pvc.Name,
+				Labels: labels,
+			},
+			Spec: pvc.Spec,
+		})
+	}
+
+	statefulSet.Annotations = map[string]string{consts.TemplateHash: TemplateHash(statefulSet.Spec.Template)}
 	return statefulSet
 }
+
+// NewLabels returns the labels shared by every resource of the single
+func NewLabels(singleGreatsql *singlev1.Single) map[string]string {
+	return map[string]string{
+		consts.AppKubernetesComponent: "controller",
+		consts.AppKubernetesName:      singleGreatsql.Name,
+	}
+}
+
+// NewOwnerReference returns the controller reference pointing at the single
+func NewOwnerReference(singleGreatsql *singlev1.Single) *metav1.OwnerReference {
+	return metav1.NewControllerRef(singleGreatsql, singlev1.GroupVersion.WithKind("Single"))
+}
+
+// PodFQDN returns the stable dns name of a statefulset member
+func PodFQDN(singleGreatsql *singlev1.Single, podName string) string {
+	return podName + "." + HeadlessServiceName(singleGreatsql) + "." + singleGreatsql.Namespace + ".svc"
+}
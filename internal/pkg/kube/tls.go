@@ -0,0 +1,193 @@
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-24 10:02:18
+ * @file: tls.go
+ * @description: certificates of encrypted connections
+ */
+
+const (
+	tlsVolume       = "tls"
+	memberTLSVolume = "member-tls"
+	// TLSDir is where the server certificate is mounted, kubelet updates the files
+	// when the secret changes
+	TLSDir = "/etc/greatsql/tls"
+	// TLSCertPath is the ssl_cert of the servers
+	TLSCertPath = TLSDir + "/" + corev1.TLSCertKey
+	// CACertKey is the key of the CA bundle in the certificate secrets
+	CACertKey = "ca.crt"
+	// CAKeyKey is the key of the CA private key in the CA secret
+	CAKeyKey = "ca.key"
+	// MemberTLSDir is where the client certificates of the members are mounted,
+	// <pod>.crt and <pod>.key next to the ca.crt of the operator CA
+	MemberTLSDir = "/etc/greatsql/member-tls"
+)
+
+// CertificateGVK is the cert-manager Certificate kind
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// CASecretName returns the name of the secret holding the CA of the single
+func CASecretName(single *singlev1.Single) string {
+	return single.Name + "-ca"
+}
+
+// TLSSecretName returns the name of the secret holding the server certificate
+func TLSSecretName(single *singlev1.Single) string {
+	if single.Spec.TLS != nil && single.Spec.TLS.Issuer == singlev1.TLSIssuerSecret {
+		return single.Spec.TLS.SecretName
+	}
+	return single.Name + "-tls"
+}
+
+// MemberTLSSecretName returns the name of the secret holding the client
+// certificates of the members
+func MemberTLSSecretName(single *singlev1.Single) string {
+	return single.Name + "-member-tls"
+}
+
+// MemberCertKey returns the key of the client certificate of a member in the member secret
+func MemberCertKey(podName string) string {
+	return podName + ".crt"
+}
+
+// MemberKeyKey returns the key of the private key of a member in the member secret
+func MemberKeyKey(podName string) string {
+	return podName + ".key"
+}
+
+// MutualTLS returns true if the members authenticate each other with client certificates
+func MutualTLS(single *singlev1.Single) bool {
+	return single.Spec.TLS != nil && single.Spec.TLS.MutualTLS && single.Spec.IsCluster()
+}
+
+// NewCertificateSecret returns a secret of the single holding a certificate
+func NewCertificateSecret(single *singlev1.Single, name string, secretType corev1.SecretType, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       single.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*NewOwnerReference(single)},
+			Labels:          NewLabels(single),
+		},
+		Data: data,
+		Type: secretType,
+	}
+}
+
+// TLSDNSNames returns the dns names clients reach the servers by, the services
+// and for the cluster types every member
+func TLSDNSNames(single *singlev1.Single) []string {
+	services := []string{single.Name}
+	if single.Spec.IsCluster() {
+		services = append(services, single.Name+"-read", "*."+HeadlessServiceName(single))
+	}
+	var names []string
+	for _, service := range services {
+		names = append(names,
+			service,
+			service+"."+single.Namespace,
+			service+"."+single.Namespace+".svc",
+			service+"."+single.Namespace+".svc.cluster.local",
+		)
+	}
+	return names
+}
+
+// NewCertificate returns the cert-manager Certificate of the server certificate,
+// cert-manager renews it into the tls secret
+func NewCertificate(single *singlev1.Single) *unstructured.Unstructured {
+	dnsNames := make([]any, 0)
+	for _, name := range TLSDNSNames(single) {
+		dnsNames = append(dnsNames, name)
+	}
+	issuerRef := single.Spec.TLS.IssuerRef
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	certificate.SetName(TLSSecretName(single))
+	certificate.SetNamespace(single.Namespace)
+	certificate.SetLabels(NewLabels(single))
+	certificate.SetOwnerReferences([]metav1.OwnerReference{*NewOwnerReference(single)})
+	certificate.Object["spec"] = map[string]any{
+		"secretName": TLSSecretName(single),
+		"commonName": single.Name,
+		"dnsNames":   dnsNames,
+		"usages":     []any{"server auth", "client auth", "digital signature", "key encipherment"},
+		"issuerRef": map[string]any{
+			"name":  issuerRef.Name,
+			"kind":  issuerRef.Kind,
+			"group": issuerRef.Group,
+		},
+		"privateKey": map[string]any{
+			"rotationPolicy": "Always",
+		},
+	}
+	return certificate
+}
+
+// newTLSArgs returns the mysqld options loading the mounted certificate
+func newTLSArgs(app *singlev1.Single) []string {
+	if app.Spec.TLS == nil {
+		return nil
+	}
+	args := []string{
+		"--ssl-ca=" + TLSDir + "/" + CACertKey,
+		"--ssl-cert=" + TLSCertPath,
+		"--ssl-key=" + TLSDir + "/" + corev1.TLSPrivateKeyKey,
+	}
+	if app.Spec.TLS.RequireSecureTransport {
+		args = append(args, "--require-secure-transport=ON")
+	}
+	return args
+}
+
+// newTLSVolumeMounts returns the mounts of the server and member certificates,
+// they are not a subPath so renewed certificates show up in the container
+func newTLSVolumeMounts(app *singlev1.Single) []corev1.VolumeMount {
+	if app.Spec.TLS == nil {
+		return nil
+	}
+	mounts := []corev1.VolumeMount{{Name: tlsVolume, MountPath: TLSDir, ReadOnly: true}}
+	if MutualTLS(app) {
+		mounts = append(mounts, corev1.VolumeMount{Name: memberTLSVolume, MountPath: MemberTLSDir, ReadOnly: true})
+	}
+	return mounts
+}
+
+// newTLSVolumes returns the volumes of the server and member certificates
+func newTLSVolumes(app *singlev1.Single) []corev1.Volume {
+	if app.Spec.TLS == nil {
+		return nil
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: tlsVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: TLSSecretName(app)},
+			},
+		},
+	}
+	if MutualTLS(app) {
+		volumes = append(volumes, corev1.Volume{
+			Name: memberTLSVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: MemberTLSSecretName(app)},
+			},
+		})
+	}
+	return volumes
+}
@@ -0,0 +1,142 @@
+package kube
+
+import (
+	singlev1 "github.com/keington/greatsql-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-20 09:14:08
+ * @file: volume.go
+ * @description: data volume selection
+ */
+
+// DataVolumeName returns the name of the volume mounted at /data
+func DataVolumeName(single *singlev1.Single) string {
+	return single.Name + "-db"
+}
+
+// NewDataVolumeSource returns the volume source of the data volume following the
+// VolumeSpec precedence: PersistentVolumeClaim, HostPath, EmptyDir. Without a
+// VolumeSpec the claim created by the operator from the storage template is used.
+func NewDataVolumeSource(single *singlev1.Single) corev1.VolumeSource {
+	volumeSpec := single.Spec.PodSpec.VolumeSpec
+	switch {
+	case volumeSpec == nil:
+	case volumeSpec.PersistentVolumeClaim != nil:
+		return corev1.VolumeSource{PersistentVolumeClaim: volumeSpec.PersistentVolumeClaim}
+	case volumeSpec.HostPath != nil:
+		return corev1.VolumeSource{HostPath: volumeSpec.HostPath}
+	case volumeSpec.EmptyDir != nil:
+		return corev1.VolumeSource{EmptyDir: volumeSpec.EmptyDir}
+	}
+
+	return corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: DataVolumeName(single),
+		},
+	}
+}
+
+// DataDir is the datadir of the my.cnf, on the data volume
+const DataDir = "/data/GreatSQL"
+
+// mount paths of the additional volumes
+const (
+	BinlogDir = "/greatsql/binlog"
+	RedoDir   = "/greatsql/redo"
+	LogDir    = "/greatsql/log"
+)
+
+// additionalVolume is a volume split off the data volume
+type additionalVolume struct {
+	name      string
+	mountPath string
+	spec      *corev1.PersistentVolumeClaimSpec
+}
+
+// additionalVolumes returns the additional volumes set in the storage spec
+func additionalVolumes(single *singlev1.Single) []additionalVolume {
+	storage := single.Spec.PodSpec.Storage
+	if storage == nil {
+		return nil
+	}
+
+	var volumes []additionalVolume
+	for _, v := range []additionalVolume{
+		{name: single.Name + "-binlog", mountPath: BinlogDir, spec: storage.BinlogVolume},
+		{name: single.Name + "-redo", mountPath: RedoDir, spec: storage.RedoVolume},
+		{name: single.Name + "-log", mountPath: LogDir, spec: storage.LogVolume},
+	} {
+		if v.spec != nil {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes
+}
+
+// AdditionalVolumeLayout returns every additional volume by name and whether the
+// storage spec sets it
+func AdditionalVolumeLayout(single *singlev1.Single) map[string]bool {
+	layout := map[string]bool{
+		single.Name + "-binlog": false,
+		single.Name + "-redo":   false,
+		single.Name + "-log":    false,
+	}
+	for _, v := range additionalVolumes(single) {
+		layout[v.name] = true
+	}
+	return layout
+}
+
+// NewAdditionalClaims returns the claims of the additional volumes
+func NewAdditionalClaims(single *singlev1.Single) []*corev1.PersistentVolumeClaim {
+	var claims []*corev1.PersistentVolumeClaim
+	for _, v := range additionalVolumes(single) {
+		spec := v.spec.DeepCopy()
+		if len(spec.AccessModes) == 0 {
+			spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+		}
+		if spec.VolumeMode == nil {
+			spec.VolumeMode = &volumeMode
+		}
+		claims = append(claims, &corev1.PersistentVolumeClaim{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      v.name,
+				Namespace: single.Namespace,
+			},
+			Spec: *spec,
+		})
+	}
+	return claims
+}
+
+// newAdditionalVolumeMounts returns the mounts of the additional volumes
+func newAdditionalVolumeMounts(single *singlev1.Single) []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	for _, v := range additionalVolumes(single) {
+		mounts = append(mounts, corev1.VolumeMount{Name: v.name, MountPath: v.mountPath})
+	}
+	return mounts
+}
+
+// newAdditionalVolumes returns the pod volumes of the additional claims, the
+// statefulset creates them from its claim templates instead
+func newAdditionalVolumes(single *singlev1.Single) []corev1.Volume {
+	var volumes []corev1.Volume
+	for _, v := range additionalVolumes(single) {
+		volumes = append(volumes, corev1.Volume{
+			Name: v.name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: v.name},
+			},
+		})
+	}
+	return volumes
+}
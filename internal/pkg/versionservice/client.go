@@ -0,0 +1,190 @@
+package versionservice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+/**
+ * @author: HuaiAn xu
+ * @date: 2026-10-21 09:20:37
+ * @file: client.go
+ * @description: version service client
+ */
+
+const (
+	// Product is the product the operator asks the version service about
+	Product = "greatsql"
+
+	requestTimeout = 10 * time.Second
+)
+
+// Version is one GreatSql release offered by the version service
+type Version struct {
+	Version     string `json:"version"`
+	Image       string `json:"image"`
+	Recommended bool   `json:"recommended,omitempty"`
+}
+
+// response is the body of GET <endpoint>/versions
+type response struct {
+	Versions []Version `json:"versions"`
+}
+
+// Client queries the version service for the available GreatSql images
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the version service at endpoint
+func NewClient(endpoint string) *Client {
+	return &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Versions returns the releases sorted from the oldest to the newest. The
+// running version is sent along so the service can tailor its answer.
+func (c *Client) Versions(ctx context.Context, current string) ([]Version, error) {
+	query := url.Values{"product": {Product}}
+	if current != "" {
+		query.Set("current", current)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/versions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("version service returned %s", resp.Status)
+	}
+
+	var r response
+	if err := sonic.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("decode version service response: %w", err)
+	}
+	versions := make([]Version, 0, len(r.Versions))
+	for _, v := range r.Versions {
+		if v.Version != "" && v.Image != "" {
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return Compare(versions[i].Version, versions[j].Version) < 0 })
+	return versions, nil
+}
+
+// Latest returns the newest version, false if there is none
+func Latest(versions []Version) (Version, bool) {
+	if len(versions) == 0 {
+		return Version{}, false
+	}
+	return versions[len(versions)-1], true
+}
+
+// Recommended returns the newest recommended version, false if there is none
+func Recommended(versions []Version) (Version, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Recommended {
+			return versions[i], true
+		}
+	}
+	return Version{}, false
+}
+
+// Resolve returns the version apply asks for, apply is "recommended", "latest"
+// or an explicit version
+func Resolve(versions []Version, apply string) (Version, error) {
+	var v Version
+	var found bool
+	switch apply {
+	case "recommended":
+		v, found = Recommended(versions)
+	case "latest":
+		v, found = Latest(versions)
+	default:
+		for _, candidate := range versions {
+			if Compare(candidate.Version, apply) == 0 {
+				v, found = candidate, true
+			}
+		}
+	}
+	if !found {
+		return Version{}, fmt.Errorf("version service offers no %s version", apply)
+	}
+	return v, nil
+}
+
+// Compare compares two versions such as 8.0.32-25, it returns -1, 0 or 1. Anything
+// after the numeric parts, e.g. the -log suffix of @@version, is ignored.
+func Compare(a, b string) int {
+	return compareParts(parts(a), parts(b))
+}
+
+// compareParts compares numeric fields from the most significant one
+func compareParts(pa, pb []int) int {
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// CompareRelease compares the major, minor and patch version only, the build
+// number does not change the data dictionary
+func CompareRelease(a, b string) int {
+	pa, pb := parts(a), parts(b)
+	if len(pa) > 3 {
+		pa = pa[:3]
+	}
+	if len(pb) > 3 {
+		pb = pb[:3]
+	}
+	return compareParts(pa, pb)
+}
+
+// parts splits a version into its leading numeric fields
+func parts(version string) []int {
+	fields := strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '-' })
+	numbers := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
@@ -0,0 +1,135 @@
+package versionservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stub serves a fixed version list and records the last query
+func stub(t *testing.T, status int, body string) (*httptest.Server, *string) {
+	t.Helper()
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/versions" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &query
+}
+
+const versionsBody = `{"versions":[
+	{"version":"8.0.32-25","image":"greatsql/greatsql:8.0.32-25"},
+	{"version":"8.0.25-16","image":"greatsql/greatsql:8.0.25-16","recommended":true},
+	{"version":"8.0.32-24","image":"greatsql/greatsql:8.0.32-24","recommended":true},
+	{"version":"","image":"greatsql/greatsql:broken"}
+]}`
+
+func TestVersions(t *testing.T) {
+	server, query := stub(t, http.StatusOK, versionsBody)
+
+	versions, err := NewClient(server.URL+"/").Versions(context.Background(), "8.0.25-16")
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if got, want := *query, "current=8.0.25-16&product=greatsql"; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+
+	want := []string{"8.0.25-16", "8.0.32-24", "8.0.32-25"}
+	if len(versions) != len(want) {
+		t.Fatalf("got %d versions, want %d", len(versions), len(want))
+	}
+	for i, v := range versions {
+		if v.Version != want[i] {
+			t.Errorf("versions[%d] = %s, want %s", i, v.Version, want[i])
+		}
+	}
+}
+
+func TestVersionsError(t *testing.T) {
+	server, _ := stub(t, http.StatusServiceUnavailable, "")
+	if _, err := NewClient(server.URL).Versions(context.Background(), ""); err == nil {
+		t.Error("expected an error for a failing version service")
+	}
+
+	server, _ = stub(t, http.StatusOK, "not json")
+	if _, err := NewClient(server.URL).Versions(context.Background(), ""); err == nil {
+		t.Error("expected an error for a malformed response")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	server, _ := stub(t, http.StatusOK, versionsBody)
+	versions, err := NewClient(server.URL).Versions(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+
+	cases := []struct {
+		apply string
+		want  string
+	}{
+		{"latest", "greatsql/greatsql:8.0.32-25"},
+		{"recommended", "greatsql/greatsql:8.0.32-24"},
+		{"8.0.25-16", "greatsql/greatsql:8.0.25-16"},
+	}
+	for _, c := range cases {
+		v, err := Resolve(versions, c.apply)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", c.apply, err)
+			continue
+		}
+		if v.Image != c.want {
+			t.Errorf("Resolve(%q) = %s, want %s", c.apply, v.Image, c.want)
+		}
+	}
+
+	if _, err := Resolve(versions, "5.7.36"); err == nil {
+		t.Error("expected an error for a version the service does not offer")
+	}
+	if _, err := Resolve(nil, "latest"); err == nil {
+		t.Error("expected an error without versions")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"8.0.32-25", "8.0.32-25", 0},
+		{"8.0.32-25-log", "8.0.32-25", 0},
+		{"8.0.25-16", "8.0.32-25", -1},
+		{"8.0.32-25", "8.0.32-24", 1},
+		{"8.4.0", "8.0.32-25", 1},
+		{"8.0.32", "8.0.32-1", -1},
+	}
+	for _, c := range cases {
+		if got := Compare(c.a, c.b); got != c.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestCompareRelease(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"8.0.32-24", "8.0.32-25", 0},
+		{"8.0.25-16", "8.0.32-25", -1},
+		{"8.4.0", "8.0.32-25", 1},
+	}
+	for _, c := range cases {
+		if got := CompareRelease(c.a, c.b); got != c.want {
+			t.Errorf("CompareRelease(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
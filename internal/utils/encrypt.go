@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"crypto/rand"
 	"encoding/base64"
+	"math/big"
 )
 
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
 /**
  * @author: HuaiAn xu
  * @date: 2024-03-21 15:29:03
@@ -25,3 +29,16 @@ func Base64Decode(data string) ([]byte, error) {
 	}
 	return decode, nil
 }
+
+// RandomPassword returns a random alphanumeric password of the given length
+func RandomPassword(length int) (string, error) {
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordChars))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordChars[n.Int64()]
+	}
+	return string(password), nil
+}
@@ -94,6 +94,11 @@ func (g *GreatSqlFinalizer) RemoveFinalizer() error {
 func (g *GreatSqlFinalizer) finalizelPersistentVolumeClaim() error {
 	logger.WithValues("Request.Finalizer.Namespace", g.GreatSql.Namespace, "Request.Finalizer.Name", g.GreatSql.Name)
 
+	// claims referenced through volumeSpec belong to the user
+	if !g.GreatSql.Spec.NeedsPersistentVolumeClaim() {
+		return nil
+	}
+
 	for i := 0; i < int(g.GreatSql.Spec.GetSize()); i++ {
 		// pvcName := g.GreatSql.Name + "-" + g.GreatSql.Name + "-" + strconv.Itoa(i)
 		pvcName := g.GreatSql.Name + "-db"